@@ -0,0 +1,479 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/mdigger/sqlgen/config"
+	"github.com/mdigger/sqlgen/generator"
+	"github.com/mdigger/sqlgen/schema"
+	"github.com/urfave/cli/v3"
+)
+
+// generatedFile описывает сгенерированный файл.
+type generatedFile struct {
+	Name   string // путь к файлу для записи
+	Source string // исходный файл с описанием запросов
+	Data   []byte // сгенерированный код
+}
+
+// buildResult содержит результат генерации кода библиотеки в памяти.
+type buildResult struct {
+	Target string          // название цели генерации
+	Out    string          // каталог для записи файлов
+	Files  []generatedFile // сгенерированные файлы
+	Cache  *buildCache     // кеш генерации для сохранения после записи файлов
+	// Inputs содержит абсолютные пути исходных файлов цели из настроек проекта для поиска
+	// потерянных файлов; для цели, заданной аргументами команды, равен nil.
+	Inputs map[string]bool
+}
+
+// buildAll выполняет генерацию кода в памяти для всех целей генерации. Все найденные
+// проблемы добавляются в общий список problems.
+func buildAll(c *cli.Context, problems *config.Errors, cached bool) ([]*buildResult, error) {
+	project, targets, err := buildTargets(c)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*buildResult, 0, len(targets))
+	for _, t := range targets {
+		result, err := build(c, project, t, problems, cached)
+		if err != nil {
+			if t.Name != "" {
+				err = fmt.Errorf("target %q: %w", t.Name, err)
+			}
+
+			return nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// buildTargets загружает настройки проекта и возвращает их вместе со списком целей генерации.
+// Если цели в настройках проекта не описаны, то единственная цель без названия формируется
+// по аргументам и флагам команды. Флаг target позволяет выбрать цели по названию.
+func buildTargets(c *cli.Context) (*config.Project, []config.Target, error) {
+	projectFile := c.Path("config")
+	project, err := config.ParseProject(configFile(projectFile))
+	if err != nil && (projectFile != "" || !errors.Is(err, os.ErrNotExist)) {
+		return nil, nil, err
+	}
+
+	if len(project.Targets) == 0 {
+		if c.IsSet("target") {
+			return nil, nil, errors.New("targets are not defined in the project configuration")
+		}
+
+		dialect := config.Dialect(c.String("dialect"))
+		if !dialect.Valid() {
+			return nil, nil, fmt.Errorf("unsupported dialect %q (expected %s, %s or %s)",
+				dialect, config.DialectPostgres, config.DialectMySQL, config.DialectSQLite)
+		}
+
+		return project, []config.Target{{
+			Sources:   c.Args().Slice(),
+			Out:       c.Path("out"),
+			Package:   c.String("name"),
+			Imports:   c.StringSlice("import"),
+			Dialect:   dialect,
+			Templates: c.Path("templates"),
+			Tests:     c.Bool("tests"),
+			Schema:    c.StringSlice("schema"),
+		}}, nil
+	}
+
+	// аргументы и флаги, описывающие библиотеку, не совместимы с целями из настроек проекта
+	for _, flag := range []string{"out", "name", "import", "templates", "dialect", "tests", "schema"} {
+		if c.IsSet(flag) {
+			return nil, nil, fmt.Errorf("flag %q can't be used with targets defined in the project configuration", flag)
+		}
+	}
+
+	if c.Args().Present() {
+		return nil, nil, errors.New("query files can't be set with targets defined in the project configuration")
+	}
+
+	names := c.StringSlice("target")
+	if len(names) == 0 {
+		return project, project.Targets, nil
+	}
+
+	// выбираем цели по названию в порядке их описания в настройках проекта
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		selected[name] = true
+	}
+
+	var targets []config.Target
+	for _, t := range project.Targets {
+		if selected[t.Name] {
+			targets = append(targets, t)
+			delete(selected, t.Name)
+		}
+	}
+
+	for _, name := range names {
+		if selected[name] {
+			return nil, nil, fmt.Errorf("target %q is not defined in the project configuration", name)
+		}
+	}
+
+	return project, targets, nil
+}
+
+// build выполняет все этапы генерации кода библиотеки для цели t в памяти, ничего не записывая
+// на диск: разбирает описания запросов, проверяет типы, импорты и тексты SQL запросов,
+// генерирует и форматирует код. Все найденные проблемы добавляются в problems. Возвращает
+// ошибку, если выполнение не может быть продолжено.
+//
+// Если cached установлен, то для файлов, описание которых не изменилось с прошлой генерации,
+// используется уже записанный код из каталога для записи файлов, а разбор, проверки и
+// генерация для них не выполняются.
+func build(c *cli.Context, project *config.Project, t config.Target, problems *config.Errors, cached bool) (*buildResult, error) {
+	// формируем список файлов с описанием запросов
+	projectFile := configFile(c.Path("config"))
+	files, err := inputFiles(t.Sources, projectFile)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &buildResult{
+		Target: t.Name,
+		Out:    t.Out, // каталог для записи файлов
+	}
+
+	if len(project.Targets) > 0 {
+		result.Inputs = make(map[string]bool, len(files))
+		for _, file := range files {
+			result.Inputs[absPath(file)] = true
+		}
+	}
+
+	name := t.Package // название пакета
+	if name == "" {
+		name = result.Out
+	}
+
+	// инициализируем генератор кода с заданным именем и списком импортируемых библиотек
+	gen := generator.New(name, t.Imports...)
+	gen.Dialect = t.Dialect
+	gen.Tests = t.Tests
+	gen.NamedTypes = project.Types
+	gen.SetInitialisms(append(project.Initialisms, t.Initialisms...)...)
+	if err := gen.SetTypes(project.SQLTypes...); err != nil {
+		return nil, err
+	}
+
+	if t.Name != "" {
+		log.Println("target:   ", t.Name)
+	}
+
+	log.Println("package:  ", gen.Package)
+
+	// заменяем стандартные шаблоны генерации пользовательскими
+	if t.Templates != "" {
+		if err := gen.SetTemplates(os.DirFS(t.Templates)); err != nil {
+			return nil, err
+		}
+	}
+
+	// загружаем структуру базы данных для определения не описанных исходящих параметров
+	if len(t.Schema) > 0 {
+		db, err := schema.Load(t.Schema...)
+		if err != nil {
+			return nil, err
+		}
+		defer db.Close()
+
+		gen.Schema = db
+	}
+
+	// загружаем кеш генерации, если он используется
+	var prev *buildCache
+	if cached {
+		projectData, _ := os.ReadFile(projectFile)
+		templatesData, err := readTemplates(t.Templates)
+		if err != nil {
+			return nil, err
+		}
+
+		schemaData, err := readFiles(t.Schema)
+		if err != nil {
+			return nil, err
+		}
+
+		options := cacheOptions(gen.Package, t.Imports, t.Tests, projectData, templatesData, schemaData)
+		prev = loadCache(result.Out, options)
+		result.Cache = newCache(options)
+	}
+
+	// обрабатываем все файлы из нашего списка параллельно, сохраняя результаты в исходном порядке
+	results := make([]fileResult, len(files))
+	parallel(c.Int("jobs"), len(files), func(i int) {
+		results[i] = buildFile(gen, prev, result.Out, files[i])
+	})
+
+	// собираем результаты и проблемы в порядке файлов, чтобы вывод не зависел от их обработки
+	var declared generator.Declarations // названия, объявленные в файлах с описанием запросов
+	for _, r := range results {
+		if r.err != nil {
+			return nil, r.err
+		}
+
+		if r.parseErr != nil {
+			problems.Add(r.parseErr)
+			continue
+		}
+
+		problems.Add(r.lintErr)
+
+		// проверяем, что названия функций и типов не пересекаются с названиями из других файлов
+		if r.queries != nil {
+			problems.Add(gen.Declare(&declared, r.file.Source, r.queries))
+		} else {
+			problems.Add(declared.Add(r.file.Source, r.names))
+		}
+
+		if r.generateErr != nil {
+			problems.Add(r.generateErr)
+		} else if result.Cache != nil {
+			outputs := []generatedFile{r.file}
+			if r.test.Name != "" {
+				outputs = append(outputs, r.test)
+			}
+
+			result.Cache.store(r.source, r.input, r.names, outputs...)
+		}
+
+		result.Files = append(result.Files, r.file)
+		if r.test.Name != "" {
+			result.Files = append(result.Files, r.test)
+		}
+	}
+
+	// генерируем код инициализации библиотеки и тестовой базы данных для тестов запросов
+	db, err := gen.DBFiles()
+	problems.Add(err)
+	for _, name := range []string{generator.DBFile, generator.DBTestFile} {
+		if data, ok := db[name]; ok {
+			result.Files = append(result.Files, generatedFile{
+				Name: filepath.Join(result.Out, name),
+				Data: data,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// fileResult содержит результат обработки одного файла с описанием запросов.
+type fileResult struct {
+	file        generatedFile   // сгенерированный файл
+	test        generatedFile   // сгенерированный файл с тестами, если они генерируются
+	source      string          // путь к исходному файлу относительно каталога для записи
+	input       []byte          // содержимое исходного файла для сохранения в кеше
+	queries     *config.Queries // описание запросов, если файл не взят из кеша
+	names       generator.Names // названия, объявленные в сгенерированном коде
+	parseErr    error           // ошибки разбора описания запросов
+	lintErr     error           // проблемы в текстах SQL запросов
+	generateErr error           // ошибки генерации кода
+	err         error           // ошибка, после которой выполнение не может быть продолжено
+}
+
+// buildFile разбирает описание запросов из файла, проверяет его и генерирует код.
+// Если описание не изменилось с прошлой генерации, то используется код из кеша prev.
+// Функция не изменяет общих данных и может выполняться параллельно для разных файлов.
+func buildFile(g generator.Generator, prev *buildCache, out, file string) fileResult {
+	// формируем новое имя файла для записи получившегося кода
+	destination := filepath.Join(out, generator.FileName(file))
+
+	r := fileResult{
+		file:   generatedFile{Name: destination, Source: file},
+		source: sourcePath(out, file),
+	}
+
+	if g.Tests {
+		r.test = generatedFile{Name: filepath.Join(out, generator.TestFileName(file)), Source: file}
+	}
+
+	// используем ранее сгенерированный код, если описание запросов не изменилось
+	if prev != nil {
+		if r.input, r.err = os.ReadFile(file); r.err != nil {
+			return r
+		}
+
+		if data, names, ok := prev.lookup(out, r.source, r.input); ok && data[destination] != nil {
+			r.file.Data, r.names = data[destination], names
+			if r.test.Data = data[r.test.Name]; r.test.Data == nil {
+				r.test = generatedFile{} // для файла без запросов тесты не генерируются
+			}
+
+			return r
+		}
+	}
+
+	// разбираем описание запроса из файла
+	qs, err := config.Parse(file)
+	if err != nil {
+		r.parseErr = err
+		return r
+	}
+
+	// определяем не описанные явно исходящие параметры по структуре базы данных
+	if err := g.InferOut(qs); err != nil {
+		r.parseErr = err
+		return r
+	}
+
+	// проверяем тексты SQL запросов
+	r.lintErr = qs.LintDialect(g.Dialect).Err()
+
+	r.queries = qs
+	r.names = g.Names(qs)
+
+	// получаем сгенерированный код с описанием запросов и их тестов
+	r.file.Data, r.test.Data, r.generateErr = g.QueryFiles(file, r.source, qs)
+	if r.generateErr == nil && r.test.Data == nil {
+		r.test = generatedFile{} // для файла без запросов тесты не генерируются
+	}
+
+	return r
+}
+
+// parallel выполняет функцию fn для всех индексов от 0 до count, используя не более jobs
+// одновременно работающих горутин. Если jobs не больше нуля, то используется количество
+// доступных процессоров.
+func parallel(jobs, count int, fn func(i int)) {
+	if jobs <= 0 {
+		jobs = runtime.GOMAXPROCS(0)
+	}
+
+	jobs = min(jobs, count)
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range jobs {
+		wg.Go(func() {
+			for i := range indexes {
+				fn(i)
+			}
+		})
+	}
+
+	for i := range count {
+		indexes <- i
+	}
+
+	close(indexes)
+	wg.Wait()
+}
+
+// inputFiles возвращает список файлов с описанием запросов, соответствующих аргументам.
+// Файл с настройками проекта в список не включается.
+func inputFiles(args []string, projectFile string) ([]string, error) {
+	list, err := matchFiles(args, projectFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// проверяем, что есть файлы с описанием запросов
+	if len(list) == 0 {
+		return nil, errors.New("the files with the description of the request were not found")
+	}
+
+	return list, nil
+}
+
+// matchFiles возвращает отсортированный список файлов, соответствующих аргументам.
+// Если аргументы не заданы, то выбираются все файлы YAML в текущем каталоге.
+// Файл с настройками проекта в список не включается.
+func matchFiles(args []string, projectFile string) ([]string, error) {
+	if len(args) == 0 {
+		args = []string{"*.yaml"}
+	}
+
+	files := make(map[string]struct{})
+	for _, arg := range args {
+		// добавляем маску для выбора файлов, если не указан конкретный файл
+		if filepath.Ext(arg) == "" {
+			arg = filepath.Join(arg, "*.yaml")
+		}
+
+		// получаем список имен файлов для обработки
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("match files %q: %w", arg, err)
+		}
+
+		// добавляем в список файлов на обработку, кроме файла с настройками проекта
+		for _, file := range matches {
+			if !sameFile(file, projectFile) {
+				files[file] = struct{}{}
+			}
+		}
+	}
+
+	// обрабатываем файлы в стабильном порядке, чтобы результат не зависел от запуска
+	list := make([]string, 0, len(files))
+	for file := range files {
+		list = append(list, file)
+	}
+
+	sort.Strings(list)
+
+	return list, nil
+}
+
+// sourcePath возвращает путь к исходному файлу относительно каталога для записи
+// сгенерированных файлов. Путь всегда использует прямой слеш в качестве разделителя,
+// чтобы сгенерированный код не зависел от операционной системы и текущего каталога.
+func sourcePath(out, file string) string {
+	if out == "" {
+		out = "."
+	}
+
+	outAbs, err := filepath.Abs(out)
+	if err != nil {
+		return filepath.ToSlash(file)
+	}
+
+	fileAbs, err := filepath.Abs(file)
+	if err != nil {
+		return filepath.ToSlash(file)
+	}
+
+	rel, err := filepath.Rel(outAbs, fileAbs)
+	if err != nil {
+		return filepath.ToSlash(file)
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+// configFile возвращает имя файла с настройками проекта.
+// Если файл не задан явно, то используется файл по умолчанию.
+func configFile(name string) string {
+	if name == "" {
+		return config.ProjectFile
+	}
+
+	return name
+}
+
+// sameFile возвращает true, если оба пути указывают на один и тот же файл.
+func sameFile(a, b string) bool {
+	a, errA := filepath.Abs(a)
+	b, errB := filepath.Abs(b)
+
+	return errA == nil && errB == nil && a == b
+}
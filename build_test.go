@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mdigger/sqlgen/config"
+	"github.com/urfave/cli/v3"
+)
+
+// writeQueries создаёт в каталоге dir файлы с описанием запросов и возвращает их имена.
+func writeQueries(t *testing.T, dir string, count int) []string {
+	t.Helper()
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, 0, count)
+	for i := range count {
+		name := fmt.Sprintf("table%02d.yaml", i)
+		data := fmt.Sprintf(`
+# Return the row.
+get row %[1]d:
+  type: one
+  sql: select id, name, created, comment from table%[1]d where id = ?
+  in:
+    id: int64
+  out:
+    id: int64
+    name: string
+    created: time.Time
+    comment: sql.NullString
+
+# Add a row.
+add row %[1]d:
+  type: exec
+  sql: insert into table%[1]d (name, data) values (?, ?)
+  in:
+    name: string
+    data: json.RawMessage
+`, i)
+
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// buildArgs выполняет генерацию кода в памяти с аргументами командной строки args и возвращает
+// сгенерированные файлы по их именам без каталога.
+func buildArgs(t *testing.T, args ...string) map[string][]byte {
+	t.Helper()
+
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	files := make(map[string][]byte)
+	app := &cli.App{
+		Name: "sqlgen",
+		Commands: []*cli.Command{{
+			Name:  "generate",
+			Flags: generateFlags,
+			Action: func(c *cli.Context) error {
+				var problems config.Errors
+				results, err := buildAll(c, &problems, false)
+				if err != nil {
+					return err
+				}
+
+				if problems.HasErrors() {
+					return problems
+				}
+
+				for _, result := range results {
+					for _, file := range result.Files {
+						files[filepath.Base(file.Name)] = file.Data
+					}
+				}
+
+				return nil
+			},
+		}},
+	}
+
+	if err := app.Run(append([]string{"sqlgen", "generate"}, args...)); err != nil {
+		t.Fatal(err)
+	}
+
+	return files
+}
+
+// compareFiles проверяет, что сгенерированные файлы побайтно совпадают.
+func compareFiles(t *testing.T, name string, got, want map[string][]byte) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("%s: got %d files, want %d", name, len(got), len(want))
+	}
+
+	for file, data := range want {
+		if !bytes.Equal(got[file], data) {
+			t.Errorf("%s: file %s differs", name, file)
+		}
+	}
+}
+
+func TestBuildDeterministic(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	names := writeQueries(t, "queries", 8)
+	args := func(jobs int, names []string) []string {
+		list := []string{"--out", "db", "--tests", "--jobs", fmt.Sprint(jobs)}
+		for _, name := range names {
+			list = append(list, filepath.Join("queries", name))
+		}
+
+		return list
+	}
+
+	want := buildArgs(t, args(1, names)...)
+	if len(want) != len(names)*2+2 {
+		t.Fatalf("got %d files, want %d", len(want), len(names)*2+2)
+	}
+
+	// те же описания дают тот же код при любом порядке файлов и числе параллельных обработчиков
+	rnd := rand.New(rand.NewPCG(1, 2))
+	for _, jobs := range []int{1, 2, 3, 0, 16} {
+		shuffled := append([]string(nil), names...)
+		rnd.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+		got := buildArgs(t, args(jobs, shuffled)...)
+		compareFiles(t, fmt.Sprintf("jobs %d, order %v", jobs, shuffled), got, want)
+	}
+}
+
+func TestBuildImportsSorted(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	writeQueries(t, "queries", 1)
+	files := buildArgs(t, "--out", "db", "queries")
+
+	want := "import (\n\t\"context\"\n\t\"database/sql\"\n\t\"encoding/json\"\n\t\"time\"\n)\n"
+	if data := string(files["table00.sql.go"]); !strings.Contains(data, want) {
+		t.Errorf("table00.sql.go: imports block not found:\n%s", data)
+	}
+}
+
+func TestBuildSourcePath(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	writeQueries(t, "queries", 2)
+	want := buildArgs(t, "--out", "db", "queries")
+
+	for name, data := range want {
+		if !strings.HasSuffix(name, ".sql.go") {
+			continue
+		}
+
+		source := "../queries/" + strings.TrimSuffix(name, ".sql.go") + ".yaml"
+		if !bytes.Contains(data, []byte("\n// source: "+source+"\n")) {
+			t.Errorf("%s: source line %q not found", name, source)
+		}
+	}
+
+	// путь к исходному файлу указывается относительно каталога для записи и не зависит
+	// от текущего каталога и формы аргументов
+	t.Chdir(filepath.Join(dir, "queries"))
+	compareFiles(t, "from queries", buildArgs(t, "--out", "../db"), want)
+	compareFiles(t, "absolute paths", buildArgs(t,
+		"--out", filepath.Join(dir, "db"), filepath.Join(dir, "queries")), want)
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mdigger/sqlgen/generator"
+)
+
+// cacheFile задаёт имя файла с кешем генерации в каталоге для записи сгенерированных файлов.
+const cacheFile = ".sqlgen.cache"
+
+// buildCache описывает кеш генерации: для каждого файла с описанием запросов сохраняется
+// хеш его содержимого и хеши сгенерированных по нему файлов. Кеш действителен только для той
+// же версии и сборки генератора и тех же параметров генерации.
+type buildCache struct {
+	Version string                `json:"version"` // версия генератора
+	Build   string                `json:"build"`   // хеш сборки генератора и стандартных шаблонов
+	Options string                `json:"options"` // хеш параметров генерации
+	Files   map[string]cacheEntry `json:"files"`   // описания файлов по исходному пути
+}
+
+// cacheEntry описывает сохранённый в кеше результат генерации одного файла.
+type cacheEntry struct {
+	Input           string            `json:"input"`   // хеш файла с описанием запросов
+	Outputs         map[string]string `json:"outputs"` // хеши сгенерированных файлов по имени
+	generator.Names                   // объявленные в сгенерированном коде названия
+}
+
+// newCache возвращает новый пустой кеш генерации для заданных параметров.
+func newCache(options string) *buildCache {
+	return &buildCache{
+		Version: generator.Version,
+		Build:   buildHash(),
+		Options: options,
+		Files:   make(map[string]cacheEntry),
+	}
+}
+
+// loadCache загружает кеш генерации из каталога out. Если кеш отсутствует, повреждён или
+// создан другой версией или сборкой генератора или для других параметров, то возвращается
+// пустой кеш.
+func loadCache(out, options string) *buildCache {
+	empty := newCache(options)
+	data, err := os.ReadFile(cachePath(out))
+	if err != nil {
+		return empty
+	}
+
+	cache := new(buildCache)
+	if err := json.Unmarshal(data, cache); err != nil || cache.Version != empty.Version ||
+		cache.Build != empty.Build || cache.Options != empty.Options || cache.Files == nil {
+		return empty
+	}
+
+	return cache
+}
+
+// lookup возвращает сохранённый ранее код файлов, сгенерированных в каталоге out по файлу
+// source с содержимым input, по их путям, и объявленные в нём названия. Код возвращается
+// только если ни один из сгенерированных файлов на диске не изменялся после генерации.
+func (c *buildCache) lookup(out, source string, input []byte) (map[string][]byte, generator.Names, bool) {
+	entry, ok := c.Files[source]
+	if !ok || entry.Input != hash(input) || len(entry.Outputs) == 0 {
+		return nil, generator.Names{}, false
+	}
+
+	files := make(map[string][]byte, len(entry.Outputs))
+	for name, sum := range entry.Outputs {
+		path := filepath.Join(out, name)
+		data, err := os.ReadFile(path)
+		if err != nil || sum != hash(data) {
+			return nil, generator.Names{}, false
+		}
+
+		files[path] = data
+	}
+
+	return files, entry.Names, true
+}
+
+// store сохраняет в кеше результат генерации файлов outputs по файлу source с содержимым input
+// и объявленные в нём названия names.
+func (c *buildCache) store(source string, input []byte, names generator.Names, outputs ...generatedFile) {
+	entry := cacheEntry{
+		Input:   hash(input),
+		Outputs: make(map[string]string, len(outputs)),
+		Names:   names,
+	}
+
+	for _, file := range outputs {
+		entry.Outputs[filepath.Base(file.Name)] = hash(file.Data)
+	}
+
+	c.Files[source] = entry
+}
+
+// file возвращает описание файла с кешем для записи в каталог out.
+func (c *buildCache) file(out string) (generatedFile, error) {
+	data, err := json.MarshalIndent(c, "", "\t")
+	if err != nil {
+		return generatedFile{}, err
+	}
+
+	return generatedFile{Name: cachePath(out), Data: append(data, '\n')}, nil
+}
+
+// saveCache записывает кеш генерации в каталог для записи файлов, если он изменился.
+func saveCache(result *buildResult) error {
+	file, err := result.Cache.file(result.Out)
+	if err != nil {
+		return err
+	}
+
+	files, err := changedFiles([]generatedFile{file})
+	if err != nil {
+		return err
+	}
+
+	return writeFiles(files)
+}
+
+// cachePath возвращает путь к файлу с кешем генерации в каталоге out.
+func cachePath(out string) string {
+	return filepath.Join(out, cacheFile)
+}
+
+// cacheOptions возвращает хеш параметров, влияющих на результат генерации: названия пакета,
+// списка импортируемых библиотек, генерации тестов, содержимого файла с настройками проекта,
+// пользовательских шаблонов и файлов со структурой базы данных.
+func cacheOptions(name string, imports []string, tests bool, project, templates, schema []byte) string {
+	imports = append([]string(nil), imports...)
+	sort.Strings(imports)
+
+	var b strings.Builder
+	b.WriteString("package:")
+	b.WriteString(name)
+	b.WriteString("\nimports:")
+	b.WriteString(strings.Join(imports, ","))
+	b.WriteString("\ntests:")
+	b.WriteString(strconv.FormatBool(tests))
+	b.WriteString("\nproject:")
+	b.WriteString(hash(project))
+	b.WriteString("\ntemplates:")
+	b.WriteString(hash(templates))
+	b.WriteString("\nschema:")
+	b.WriteString(hash(schema))
+
+	return hash([]byte(b.String()))
+}
+
+// readTemplates возвращает названия и содержимое всех файлов пользовательских шаблонов
+// из каталога dir для вычисления хеша. Если каталог не задан, то возвращается nil.
+func readTemplates(dir string) ([]byte, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	names, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+
+	return readFiles(names)
+}
+
+// readFiles возвращает названия файлов и хеши их содержимого для вычисления хеша.
+func readFiles(names []string) ([]byte, error) {
+	var data []byte
+	for _, name := range names {
+		content, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		data = append(data, filepath.Base(name)...)
+		data = append(data, 0)
+		data = append(data, hash(content)...)
+		data = append(data, '\n')
+	}
+
+	return data, nil
+}
+
+// buildHash возвращает хеш сведений о сборке генератора (версии модуля и ревизии исходного
+// кода) и стандартных шаблонов, чтобы пересобранный или обновлённый генератор с другими
+// шаблонами или правилами формирования кода не использовал результаты из кеша.
+func buildHash() string {
+	var b strings.Builder
+	if info, ok := debug.ReadBuildInfo(); ok {
+		b.WriteString(info.Main.Version)
+		b.WriteString(info.Main.Sum)
+		for _, setting := range info.Settings {
+			if strings.HasPrefix(setting.Key, "vcs.") {
+				b.WriteString("\n" + setting.Key + "=" + setting.Value)
+			}
+		}
+	}
+
+	b.WriteString("\ntemplates:")
+	b.WriteString(hash([]byte(generator.Templates())))
+
+	return hash([]byte(b.String()))
+}
+
+// hash возвращает хеш содержимого в виде строки.
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mdigger/sqlgen/generator"
+)
+
+// storeFiles записывает сгенерированные файлы в каталог out и сохраняет их в кеше.
+func storeFiles(t *testing.T, cache *buildCache, out, source string, input []byte, files ...generatedFile) {
+	t.Helper()
+
+	for i := range files {
+		files[i].Name = filepath.Join(out, files[i].Name)
+		if err := os.WriteFile(files[i].Name, files[i].Data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cache.store(source, input, generator.Names{Funcs: []string{"GetUser"}}, files...)
+}
+
+func TestCacheLookup(t *testing.T) {
+	out := t.TempDir()
+	input := []byte("get user: ...")
+	code := filepath.Join(out, "users.sql.go")
+	test := filepath.Join(out, "users_sql_test.go")
+
+	for _, tt := range []struct {
+		name   string
+		change func(t *testing.T)
+		input  []byte
+		hit    bool
+	}{
+		{name: "unchanged", input: input, hit: true},
+		{name: "changed input", input: []byte("get user: changed"), hit: false},
+		{name: "missing output", input: input, hit: false, change: func(t *testing.T) {
+			if err := os.Remove(test); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{name: "changed output", input: input, hit: false, change: func(t *testing.T) {
+			if err := os.WriteFile(code, []byte("package edited\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	} {
+		cache := newCache("options")
+		storeFiles(t, cache, out, "users.yaml", input,
+			generatedFile{Name: "users.sql.go", Data: []byte("package db\n")},
+			generatedFile{Name: "users_sql_test.go", Data: []byte("package db\n\n// test\n")})
+
+		if tt.change != nil {
+			tt.change(t)
+		}
+
+		files, names, ok := cache.lookup(out, "users.yaml", tt.input)
+		if ok != tt.hit {
+			t.Errorf("%s: lookup = %v, want %v", tt.name, ok, tt.hit)
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		if len(files) != 2 || string(files[code]) != "package db\n" || files[test] == nil {
+			t.Errorf("%s: files = %q", tt.name, files)
+		}
+
+		if !slices.Equal(names.Funcs, []string{"GetUser"}) {
+			t.Errorf("%s: names = %v", tt.name, names)
+		}
+	}
+
+	if _, _, ok := newCache("options").lookup(out, "users.yaml", input); ok {
+		t.Error("empty cache: lookup succeeded")
+	}
+}
+
+func TestLoadCache(t *testing.T) {
+	input := []byte("get user: ...")
+	for _, tt := range []struct {
+		name    string
+		options string
+		change  func(c *buildCache)
+		data    string // содержимое файла кеша вместо сохранённого
+		hit     bool
+	}{
+		{name: "same build and options", options: "options", hit: true},
+		{name: "changed options", options: "other", hit: false},
+		{name: "other build", options: "options", hit: false, change: func(c *buildCache) {
+			c.Build = hash([]byte("other build"))
+		}},
+		{name: "other version", options: "options", hit: false, change: func(c *buildCache) {
+			c.Version += ".1"
+		}},
+		{name: "old format", options: "options", hit: false,
+			data: `{"version":"` + generator.Version + `","options":"options","files":{"users.yaml":{"input":"","outputs":["users.sql.go"]}}}`},
+		{name: "corrupted", options: "options", hit: false, data: `{"version":`},
+	} {
+		out := t.TempDir()
+		cache := newCache("options")
+		storeFiles(t, cache, out, "users.yaml", input,
+			generatedFile{Name: "users.sql.go", Data: []byte("package db\n")})
+
+		if tt.change != nil {
+			tt.change(cache)
+		}
+
+		file, err := cache.file(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if tt.data != "" {
+			file.Data = []byte(tt.data)
+		}
+
+		if err := os.WriteFile(file.Name, file.Data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		loaded := loadCache(out, tt.options)
+		if loaded.Options != tt.options || loaded.Build != buildHash() || loaded.Files == nil {
+			t.Errorf("%s: loaded cache is not valid: %+v", tt.name, loaded)
+		}
+
+		if _, _, ok := loaded.lookup(out, "users.yaml", input); ok != tt.hit {
+			t.Errorf("%s: lookup = %v, want %v", tt.name, ok, tt.hit)
+		}
+	}
+}
+
+func TestCacheOptions(t *testing.T) {
+	base := cacheOptions("db", []string{"a", "b"}, false, nil, nil, nil)
+	if got := cacheOptions("db", []string{"b", "a"}, false, nil, nil, nil); got != base {
+		t.Error("options depend on the imports order")
+	}
+
+	for name, got := range map[string]string{
+		"package":   cacheOptions("other", []string{"a", "b"}, false, nil, nil, nil),
+		"imports":   cacheOptions("db", []string{"a"}, false, nil, nil, nil),
+		"tests":     cacheOptions("db", []string{"a", "b"}, true, nil, nil, nil),
+		"project":   cacheOptions("db", []string{"a", "b"}, false, []byte("types:"), nil, nil),
+		"templates": cacheOptions("db", []string{"a", "b"}, false, nil, []byte("x.tmpl"), nil),
+		"schema":    cacheOptions("db", []string{"a", "b"}, false, nil, nil, []byte("create table")),
+	} {
+		if got == base {
+			t.Errorf("changed %s: options hash not changed", name)
+		}
+	}
+
+	if buildHash() != buildHash() {
+		t.Error("build hash is not stable")
+	}
+}
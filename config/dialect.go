@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Dialect описывает диалект SQL, используемый в запросах.
+type Dialect string
+
+// Поддерживаемые диалекты SQL.
+const (
+	DialectAny      Dialect = ""         // диалект не задан: проверки диалекта не выполняются
+	DialectPostgres Dialect = "postgres" // PostgreSQL
+	DialectMySQL    Dialect = "mysql"    // MySQL и MariaDB
+	DialectSQLite   Dialect = "sqlite"   // SQLite
+)
+
+// Valid возвращает true, если диалект поддерживается.
+func (d Dialect) Valid() bool {
+	switch d {
+	case DialectAny, DialectPostgres, DialectMySQL, DialectSQLite:
+		return true
+	default:
+		return false
+	}
+}
+
+// UnmarshalYAML разбирает и проверяет название диалекта SQL.
+func (d *Dialect) UnmarshalYAML(n *yaml.Node) error {
+	var s string
+	if err := n.Decode(&s); err != nil {
+		return err
+	}
+
+	if dialect := Dialect(s); dialect.Valid() {
+		*d = dialect
+		return nil
+	}
+
+	return fmt.Errorf("line %d: unsupported dialect %q (expected %s, %s or %s)",
+		n.Line, s, DialectPostgres, DialectMySQL, DialectSQLite)
+}
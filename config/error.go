@@ -1,22 +1,147 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
 
 	"gopkg.in/yaml.v3"
 )
 
+// Code описывает стабильный код ошибки, по которому её можно идентифицировать
+// независимо от текста сообщения.
+type Code string
+
+// Коды ошибок разбора и проверки описания запросов.
+const (
+	CodeSyntax          Code = "SG001" // синтаксическая ошибка YAML
+	CodeStructure       Code = "SG002" // неверная структура описания (ожидается другой тип YAML-ноды)
+	CodeRedefined       Code = "SG003" // повторное определение запроса или поля
+	CodeUnknownProperty Code = "SG004" // неизвестное свойство
+	CodeQueryType       Code = "SG005" // неподдерживаемый тип запроса
+	CodeOutParams       Code = "SG006" // описание исходящих параметров не соответствует типу запроса
+	CodeUndefined       Code = "SG007" // не задано обязательное значение
+	CodeFieldType       Code = "SG008" // некорректное описание типа поля
+	CodePointer         Code = "SG009" // неподдерживаемый указатель в типе поля
+	CodeGoName          Code = "SG010" // некорректное название идентификатора golang
+	CodeNameConflict    Code = "SG011" // пересечение названий в сгенерированном коде
+	CodeUnknownPackage  Code = "SG012" // неизвестный префикс пакета
+	CodeScan            Code = "SG013" // тип не поддерживает чтение из базы данных
+	CodeParamCount      Code = "SG014" // количество параметров запроса не совпадает с описанием
+	CodeSelectAll       Code = "SG015" // использование SELECT *
+	CodeStatementKind   Code = "SG016" // вид SQL запроса не соответствует типу запроса
+	CodePlaceholder     Code = "SG017" // стиль параметров не соответствует диалекту SQL
+	CodeSchema          Code = "SG018" // запрос или схема базы данных не разбираются SQLite
+	CodeColumnCount     Code = "SG019" // количество колонок ответа не совпадает с описанием
+	CodeColumnName      Code = "SG020" // название колонки ответа не совпадает с названием поля
+	CodeColumnType      Code = "SG021" // тип колонки ответа не соответствует типу поля
+	CodeGenerate        Code = "SG100" // ошибка генерации кода
+	CodeMissing         Code = "SG101" // сгенерированный файл отсутствует
+	CodeStale           Code = "SG102" // сгенерированный файл устарел
+	CodeExtra           Code = "SG103" // лишний сгенерированный файл
+)
+
+// codeDescriptions содержит краткие описания кодов ошибок.
+var codeDescriptions = map[Code]string{
+	CodeSyntax:          "YAML syntax error",
+	CodeStructure:       "invalid description structure",
+	CodeRedefined:       "query or field redefined",
+	CodeUnknownProperty: "unknown property",
+	CodeQueryType:       "unsupported query type",
+	CodeOutParams:       "outgoing parameters do not match the query type",
+	CodeUndefined:       "required value not defined",
+	CodeFieldType:       "invalid field type",
+	CodePointer:         "unsupported field type pointer",
+	CodeGoName:          "invalid go_name",
+	CodeNameConflict:    "generated names conflict",
+	CodeUnknownPackage:  "unknown package prefix",
+	CodeScan:            "type can't be scanned from database",
+	CodeParamCount:      "query parameters count mismatch",
+	CodeSelectAll:       "SELECT * used",
+	CodeStatementKind:   "SQL statement does not match the query type",
+	CodePlaceholder:     "placeholder style does not match the SQL dialect",
+	CodeSchema:          "query or schema is invalid for the database",
+	CodeColumnCount:     "result columns count mismatch",
+	CodeColumnName:      "result column name does not match the field",
+	CodeColumnType:      "result column type does not match the field type",
+	CodeGenerate:        "code generation error",
+	CodeMissing:         "generated file is missing",
+	CodeStale:           "generated file is out of date",
+	CodeExtra:           "generated file is obsolete",
+}
+
+// Codes возвращает отсортированный список всех кодов ошибок.
+func Codes() []Code {
+	list := make([]Code, 0, len(codeDescriptions))
+	for code := range codeDescriptions {
+		list = append(list, code)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+
+	return list
+}
+
+// Description возвращает краткое описание кода ошибки.
+func (c Code) Description() string {
+	return codeDescriptions[c]
+}
+
+// Severity описывает уровень важности сообщения.
+type Severity uint8
+
+// Поддерживаемые уровни важности сообщений.
+const (
+	SeverityError   Severity = iota // ошибка
+	SeverityWarning                 // предупреждение
+)
+
+// String возвращает строковое представление уровня важности.
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "error"
+	}
+}
+
 // Error описывает ошибку разбора конфигурации.
 type Error struct {
-	Message  string     // сообщение об ошибке
-	Query    string     // название запроса
-	err      error      // оригинальная ошибка
-	position `yaml:"-"` // строка и позиция в исходном файле
+	Severity Severity // уровень важности
+	Code     Code     // код ошибки
+	Message  string   // сообщение об ошибке
+	Query    string   // название запроса
+	File     string   // имя исходного файла
+	Line     int      // номер строки в исходном файле, начиная с 1
+	Column   int      // номер колонки в строке, начиная с 1
+	Length   int      // длина фрагмента с ошибкой в символах
+	Snippet  string   // строка исходного файла, к которой относится ошибка
+	err      error    // оригинальная ошибка
 }
 
-// Error возвращает строку с описанием ошибки.
-func (e Error) Error() string {
-	message := fmt.Sprintf("[%v] %q %s", e.Source(), e.Query, e.Message)
+// Pos возвращает строковое представление файла, строки и колонки с ошибкой.
+func (e Error) Pos() string {
+	var pos []string
+	if e.File != "" {
+		pos = append(pos, e.File)
+	}
+
+	if e.Line > 0 {
+		pos = append(pos, fmt.Sprint(e.Line))
+		if e.Column > 0 {
+			pos = append(pos, fmt.Sprint(e.Column))
+		}
+	}
+
+	return strings.Join(pos, ":")
+}
+
+// Text возвращает описание ошибки без позиции, названия запроса и фрагмента исходного файла.
+func (e Error) Text() string {
+	message := e.Message
 	if e.err != nil {
 		message += ": " + e.err.Error()
 	}
@@ -24,22 +149,175 @@ func (e Error) Error() string {
 	return message
 }
 
+// Error возвращает строку с описанием ошибки.
+// Если известна строка исходного файла, то она добавляется к описанию
+// с выделением места ошибки.
+func (e Error) Error() string {
+	var buf strings.Builder
+	if pos := e.Pos(); pos != "" {
+		buf.WriteString(pos)
+		buf.WriteString(": ")
+	}
+
+	buf.WriteString(e.Severity.String())
+	if e.Code != "" {
+		fmt.Fprintf(&buf, "[%s]", e.Code)
+	}
+
+	buf.WriteString(": ")
+	buf.WriteString(e.Text())
+	if e.Query != "" {
+		fmt.Fprintf(&buf, " (query %q)", e.Query)
+	}
+
+	if e.Snippet == "" {
+		return buf.String()
+	}
+
+	gutter := fmt.Sprintf("%5d | ", e.Line)
+	fmt.Fprintf(&buf, "\n%s%s", gutter, e.Snippet)
+
+	if e.Column > 0 {
+		fmt.Fprintf(&buf, "\n%*s| ", len(gutter)-2, "")
+
+		// сохраняем табуляции, чтобы выделение совпадало с текстом строки
+		for i, r := range e.Snippet {
+			if utf8.RuneCountInString(e.Snippet[:i]) >= e.Column-1 {
+				break
+			}
+
+			if r == '\t' {
+				buf.WriteRune('\t')
+			} else {
+				buf.WriteRune(' ')
+			}
+		}
+
+		buf.WriteString(strings.Repeat("^", max(e.Length, 1)))
+	}
+
+	return buf.String()
+}
+
 // Unwrap возвращает оригинальную ошибку.
 func (e Error) Unwrap() error {
 	return e.err
 }
 
-// NewError формирует и возвращает описание ошибки при разборе запроса.
-func NewError(err error, n *yaml.Node, format string, args ...any) error {
-	qerr := Error{
-		Message: fmt.Sprintf(format, args...),
-		err:     err,
+// Errors содержит список ошибок разбора и проверки описания запросов.
+type Errors []Error
+
+// Error возвращает описание всех ошибок, каждую с новой строки.
+func (e Errors) Error() string {
+	list := make([]string, len(e))
+	for i, err := range e {
+		list[i] = err.Error()
 	}
 
-	if n != nil {
-		qerr.Query = n.Value
-		qerr.position = parseSource(n)
+	return strings.Join(list, "\n")
+}
+
+// Unwrap возвращает список ошибок для поддержки [errors.Is] и [errors.As].
+func (e Errors) Unwrap() []error {
+	list := make([]error, len(e))
+	for i, err := range e {
+		list[i] = err
+	}
+
+	return list
+}
+
+// Err возвращает nil, если список ошибок пуст.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
 	}
 
+	return e
+}
+
+// HasErrors возвращает true, если список содержит хотя бы одну ошибку,
+// а не только предупреждения.
+func (e Errors) HasErrors() bool {
+	for _, err := range e {
+		if err.Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Add добавляет ошибку в список. Вложенные списки ошибок разворачиваются,
+// а ошибки других типов добавляются без информации о позиции.
+func (e *Errors) Add(err error) {
+	var (
+		list Errors
+		qerr Error
+	)
+
+	switch {
+	case err == nil:
+	case errors.As(err, &list):
+		*e = append(*e, list...)
+	case errors.As(err, &qerr):
+		*e = append(*e, qerr)
+	default:
+		*e = append(*e, Error{Message: err.Error()})
+	}
+}
+
+// NewError формирует и возвращает описание ошибки при разборе запроса.
+// Позиция ошибки соответствует YAML-ноде n.
+func NewError(code Code, err error, n *yaml.Node, format string, args ...any) error {
+	return parseSource(n).error(code, err, format, args...)
+}
+
+// Errorf формирует и возвращает описание ошибки, связанной с описанием запроса.
+// Позиция ошибки соответствует названию запроса в исходном файле.
+func (q Query) Errorf(code Code, err error, format string, args ...any) error {
+	qerr := q.position.error(code, err, format, args...).(Error)
+	qerr.Query = q.Name
+
+	return qerr
+}
+
+// SQLErrorf формирует и возвращает описание ошибки, связанной с текстом SQL запроса.
+// Позиция ошибки соответствует тексту SQL запроса в исходном файле.
+func (q Query) SQLErrorf(code Code, err error, format string, args ...any) error {
+	qerr := q.SQL.position.error(code, err, format, args...).(Error)
+	qerr.Query = q.Name
+
+	return qerr
+}
+
+// OutErrorf формирует и возвращает описание ошибки, связанной с описанием исходящих параметров.
+// Позиция ошибки соответствует описанию исходящих параметров в исходном файле, а если
+// они не описаны — названию запроса.
+func (q Query) OutErrorf(code Code, err error, format string, args ...any) error {
+	qerr := q.Out.position.error(code, err, format, args...).(Error)
+	qerr.Query = q.Name
+
+	return qerr
+}
+
+// FieldErrorf формирует и возвращает описание ошибки, связанной с описанием поля запроса.
+// Позиция ошибки соответствует строке с определением поля в исходном файле.
+func (q Query) FieldErrorf(f Field, code Code, err error, format string, args ...any) error {
+	qerr := f.position.error(code, err, format, args...).(Error)
+	qerr.Query = q.Name
+
 	return qerr
 }
+
+// Errorf формирует и возвращает описание ошибки, связанной с описанием значения перечисления.
+// Позиция ошибки соответствует названию значения в исходном файле.
+func (v EnumValue) Errorf(code Code, err error, format string, args ...any) error {
+	return v.position.error(code, err, format, args...)
+}
+
+// Errorf формирует и возвращает описание ошибки, связанной с описанием типа.
+// Позиция ошибки соответствует названию типа в исходном файле.
+func (t NamedType) Errorf(code Code, err error, format string, args ...any) error {
+	return t.position.error(code, err, format, args...)
+}
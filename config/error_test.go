@@ -0,0 +1,93 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// golden сравнивает данные с содержимым файла testdata/name.golden. С флагом -update
+// файл перезаписывается.
+func golden(t *testing.T, name string, data []byte) {
+	t.Helper()
+
+	filename := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(filename, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		return
+	}
+
+	want, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != string(want) {
+		t.Errorf("%s mismatch:\n--- got\n%s\n--- want\n%s", filename, data, want)
+	}
+}
+
+// errorInputs содержит описания запросов с ошибками в разных позициях файла.
+var errorInputs = []struct {
+	name string
+	data string
+}{{
+	name: "tab_separated",
+	data: "get user:\n  type:\tsingle\n  sql: select id from users\n",
+}, {
+	name: "tab_indented",
+	data: "get user:\n\ttype: one\n",
+}, {
+	name: "multiline",
+	data: `# Return the user.
+get user:
+  type: one
+  sql: |
+    select id
+    from users
+    where id = ?
+  out:
+    id: int64
+
+# Return all users.
+list users:
+  type: many
+  sql: >
+    select id, name
+    from users where id = ? and name = ?
+  in:
+    id: int64
+  out:
+    id: int64
+    name: string
+`,
+}, {
+	name: "field_type",
+	data: "get user:\n  type: one\n  sql: select id from users where id = ?\n  in:\n    id: int64\n  out:\n    id:\t'int 64'\n",
+}, {
+	name: "unicode",
+	data: "# Возвращает пользователя.\nпользователь:\n  type: one\n  sql: select id from users where id = ? and x = ?\n  in:\n    идентификатор: int64\n  out:\n    id: int64\n",
+}}
+
+func TestErrorText(t *testing.T) {
+	for _, tt := range errorInputs {
+		var errs Errors
+		qs, err := ParseBytes("queries.yaml", []byte(tt.data))
+		errs.Add(err)
+		if err == nil {
+			errs = append(errs, qs.LintDialect(DialectSQLite)...)
+		}
+
+		if len(errs) == 0 {
+			t.Fatalf("%s: no errors", tt.name)
+		}
+
+		golden(t, "error_"+tt.name, []byte(errs.Error()+"\n"))
+	}
+}
@@ -8,6 +8,7 @@ import (
 type Field struct {
 	Name     string     // название
 	Type     string     // идентификатор типа данных
+	GoName   string     // явно заданное название поля в golang
 	Comment  Comment    // комментарий
 	position `yaml:"-"` // позиция в исходном файле
 }
@@ -19,10 +20,16 @@ type Fields struct {
 	index    map[string]int // индекс с идентификаторами полей
 	Anchor   string         // название для ссылки
 	Alias    string         // имя ссылки на исходные данные
+	Auto     bool           // поля определяются по структуре базы данных
 	position `yaml:"-"`     // позиция в исходном файле
 }
 
+// AutoFields задаёт значение, которое вместо списка полей указывает, что поля определяются
+// по структуре базы данных.
+const AutoFields = "auto"
+
 // UnmarshalYAML реализует интерфейс [yaml.Unmarshaler].
+// Возвращает список всех найденных в описании полей ошибок [Errors].
 func (fs *Fields) UnmarshalYAML(n *yaml.Node) error {
 	fs.Anchor = n.Anchor // сохраняем имя ссылки
 
@@ -32,8 +39,16 @@ func (fs *Fields) UnmarshalYAML(n *yaml.Node) error {
 		fs.Alias = n.Anchor // запоминаем имя ссылки
 	}
 
+	// поля будут определены по структуре базы данных
+	if n.Kind == yaml.ScalarNode && n.Value == AutoFields {
+		fs.position = parseSource(n)
+		fs.Auto = true
+
+		return nil
+	}
+
 	if n.Kind != yaml.MappingNode {
-		return NewError(nil, n, "fields must be a YAML mapping: have %v", n.Kind)
+		return NewError(CodeStructure, nil, n, "fields must be a YAML mapping: have %v", kindName(n.Kind))
 	}
 
 	// сохраняем позицию в исходном файле с определением элемента списка
@@ -45,38 +60,110 @@ func (fs *Fields) UnmarshalYAML(n *yaml.Node) error {
 	fs.index = make(map[string]int, count)
 
 	// разбираем дерево с описанием полей в формате YAML
+	var errs Errors
 	for i := 1; i < len(n.Content); i += 2 {
-		var f Field
+		nameNode, valueNode := n.Content[i-1], n.Content[i]
 
-		nameNode := n.Content[i-1] // информация о названии
-		f.Name = nameNode.Value    // сохраняем название запроса
-		if f.Name == "" {
-			return NewError(nil, nameNode, "field name not defined")
+		f, err := parseField(nameNode, valueNode)
+		if err != nil {
+			errs.Add(err)
+			continue
 		}
 
 		// проверяем, что такое поле ещё не было определено ранее
 		if _, ok := fs.index[f.Name]; ok {
-			return NewError(nil, nameNode, "field %q redefined", f.Name)
+			errs.Add(NewError(CodeRedefined, nil, nameNode, "field %q redefined", f.Name))
+			continue
 		}
 
-		// сохраняем позицию в исходном файле с определением элемента списка
-		f.position = parseSource(nameNode)
-
-		// разбираем поля с описанием типа
-		valueNode := n.Content[i]
-		// тип данных поля
-		f.Type = valueNode.Value
-		if f.Type == "" {
-			return NewError(nil, valueNode, "field %q type not defined", f.Name)
-		}
-
-		// комментарий
-		f.Comment = parseComments(nameNode, valueNode)
-
 		// сохраняем разобранный запрос и его индекс
 		fs.Fields = append(fs.Fields, f)
 		fs.index[f.Name] = len(fs.Fields) - 1
 	}
 
+	return errs.Err()
+}
+
+// Add добавляет в список поле с заданным названием и типом, которые определены не по описанию
+// в исходном файле, а, например, по структуре базы данных. Позиция поля соответствует позиции
+// описания списка полей. Возвращает ошибку, если поле с таким названием уже есть в списке.
+func (fs *Fields) Add(name, typ string) error {
+	if _, ok := fs.index[name]; ok {
+		return fs.position.error(CodeRedefined, nil, "field %q redefined", name)
+	}
+
+	if fs.index == nil {
+		fs.index = make(map[string]int)
+	}
+
+	fs.Fields = append(fs.Fields, Field{Name: name, Type: typ, position: fs.position})
+	fs.index[name] = len(fs.Fields) - 1
+
 	return nil
 }
+
+// parseField разбирает описание поля запроса.
+func parseField(nameNode, valueNode *yaml.Node) (Field, error) {
+	var f Field
+
+	f.Name = nameNode.Value // сохраняем название запроса
+	if f.Name == "" {
+		return f, NewError(CodeUndefined, nil, nameNode, "field name not defined")
+	}
+
+	// сохраняем позицию в исходном файле с определением элемента списка
+	f.position = parseSource(nameNode)
+
+	// разбираем поля с описанием типа
+	var errs Errors
+	typeNode := valueNode
+	switch valueNode.Kind {
+	case yaml.ScalarNode:
+		// краткая форма описания поля: "name: type"
+
+	case yaml.MappingNode:
+		// полная форма описания поля с дополнительными свойствами
+		typeNode = nil
+		for j := 1; j < len(valueNode.Content); j += 2 {
+			propNode, propValueNode := valueNode.Content[j-1], valueNode.Content[j]
+			switch propNode.Value {
+			case "type":
+				typeNode = propValueNode
+			case "go_name":
+				f.GoName = propValueNode.Value
+				if err := checkGoName(f.GoName); err != nil {
+					errs.Add(NewError(CodeGoName, err, propValueNode, "field %q go_name", f.Name))
+				}
+			default:
+				errs.Add(NewError(CodeUnknownProperty, nil, propNode,
+					"unknown field %q property %q", f.Name, propNode.Value))
+			}
+		}
+
+		if typeNode == nil {
+			errs.Add(NewError(CodeUndefined, nil, nameNode, "field %q type not defined", f.Name))
+			return f, errs
+		}
+
+	default:
+		return f, NewError(CodeStructure, nil, valueNode,
+			"field %q must be a type name or a YAML mapping: have %v", f.Name, kindName(valueNode.Kind))
+	}
+
+	// тип данных поля
+	f.Type = typeNode.Value
+	if f.Type == "" {
+		errs.Add(NewError(CodeUndefined, nil, typeNode, "field %q type not defined", f.Name))
+		return f, errs
+	}
+
+	// проверяем, что тип описан в соответствии с синтаксисом golang
+	if _, err := ParseType(f.Type); err != nil {
+		errs.Add(NewError(CodeFieldType, err, typeNode, "field %q type", f.Name))
+	}
+
+	// комментарий
+	f.Comment = parseComments(nameNode, valueNode)
+
+	return f, errs.Err()
+}
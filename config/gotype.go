@@ -0,0 +1,87 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// ParseType разбирает описание типа данных в формате golang и возвращает его синтаксическое дерево.
+// Возвращает ошибку, если строка не является корректным описанием типа.
+func ParseType(s string) (ast.Expr, error) {
+	expr, err := parser.ParseExpr(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid type %q: %w", s, err)
+	}
+
+	if err := checkTypeExpr(expr); err != nil {
+		return nil, fmt.Errorf("invalid type %q: %w", s, err)
+	}
+
+	return expr, nil
+}
+
+// checkTypeExpr проверяет, что выражение описывает тип данных.
+func checkTypeExpr(expr ast.Expr) error {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return nil
+
+	case *ast.SelectorExpr:
+		// поддерживается только тип из другого пакета: prefix.Type
+		if _, ok := t.X.(*ast.Ident); !ok {
+			return errors.New("unsupported package selector")
+		}
+
+		return nil
+
+	case *ast.StarExpr:
+		return checkTypeExpr(t.X)
+
+	case *ast.ArrayType:
+		// размер массива может быть задан только числом
+		if t.Len != nil {
+			if lit, ok := t.Len.(*ast.BasicLit); !ok || lit.Kind != token.INT {
+				return errors.New("array length must be an integer")
+			}
+		}
+
+		return checkTypeExpr(t.Elt)
+
+	case *ast.MapType:
+		if err := checkTypeExpr(t.Key); err != nil {
+			return err
+		}
+
+		return checkTypeExpr(t.Value)
+
+	case *ast.InterfaceType:
+		// поддерживается только пустой интерфейс
+		if t.Methods != nil && len(t.Methods.List) > 0 {
+			return errors.New("interface with methods is not supported")
+		}
+
+		return nil
+
+	case *ast.ParenExpr:
+		return checkTypeExpr(t.X)
+
+	default:
+		return errors.New("not a type expression")
+	}
+}
+
+// checkGoName проверяет, что строка может использоваться в качестве названия экспортируемого
+// идентификатора golang.
+func checkGoName(s string) error {
+	switch {
+	case !token.IsIdentifier(s):
+		return fmt.Errorf("%q is not a valid Go identifier", s)
+	case !token.IsExported(s):
+		return fmt.Errorf("%q is not an exported Go identifier", s)
+	default:
+		return nil
+	}
+}
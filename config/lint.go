@@ -0,0 +1,240 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Lint проверяет тексты SQL запросов на соответствие их описанию и возвращает список
+// найденных проблем. Проблемы, которые не мешают генерации кода, возвращаются
+// с уровнем важности [SeverityWarning].
+func (qs Queries) Lint() Errors {
+	return qs.LintDialect(DialectAny)
+}
+
+// LintDialect выполняет те же проверки, что и [Queries.Lint], и дополнительно проверяет,
+// что стиль параметров в текстах SQL запросов соответствует диалекту SQL.
+func (qs Queries) LintDialect(dialect Dialect) Errors {
+	var errs Errors
+	for _, q := range qs.Queries {
+		errs = append(errs, q.lint(dialect)...)
+	}
+
+	return errs
+}
+
+// Lint проверяет текст SQL запроса на соответствие описанию запроса.
+func (q Query) Lint() Errors {
+	return q.lint(DialectAny)
+}
+
+// lint проверяет текст SQL запроса на соответствие описанию запроса и диалекту SQL.
+func (q Query) lint(dialect Dialect) Errors {
+	var errs Errors
+	add := func(severity Severity, code Code, format string, args ...any) {
+		qerr := q.SQL.position.error(code, nil, format, args...).(Error)
+		qerr.Severity = severity
+		qerr.Query = q.Name
+		errs = append(errs, qerr)
+	}
+
+	tokens := sqlTokens(q.SQL.Query)
+	if len(tokens) == 0 {
+		add(SeverityError, CodeUndefined, "sql query not defined")
+		return errs
+	}
+
+	// количество параметров в запросе должно соответствовать описанию входящих параметров;
+	// если диалект не задан, то параметры определяются приблизительно, поэтому несоответствие
+	// не считается ошибкой
+	count := paramsCount(tokens, dialect)
+	if count != len(q.In.Fields) {
+		severity := SeverityError
+		if dialect == DialectAny {
+			severity = SeverityWarning
+		}
+
+		add(severity, CodeParamCount,
+			"query uses %d parameter(s), but %d described", count, len(q.In.Fields))
+	}
+
+	// стиль параметров должен поддерживаться диалектом SQL; в PostgreSQL символ ? является
+	// оператором jsonb, поэтому о нём сообщается, только если описано больше параметров
+	positional, numbered := placeholders(tokens)
+	switch {
+	case dialect == DialectPostgres && positional && count < len(q.In.Fields):
+		add(SeverityWarning, CodePlaceholder,
+			"%s uses numbered parameters ($1), but query uses ?", dialect)
+	case dialect == DialectMySQL && numbered:
+		add(SeverityWarning, CodePlaceholder,
+			"%s uses positional parameters (?), but query uses numbered parameters", dialect)
+	}
+
+	// использование SELECT * небезопасно при изменении структуры таблицы
+	for i := 1; i < len(tokens); i++ {
+		if tokens[i] == "*" && strings.EqualFold(tokens[i-1], "select") {
+			add(SeverityWarning, CodeSelectAll,
+				"SELECT * is unsafe: the order of columns depends on the table structure")
+			break
+		}
+	}
+
+	// проверяем, что вид запроса соответствует его типу
+	kind := strings.ToUpper(tokens[0])
+	returning := false
+	for _, token := range tokens {
+		if strings.EqualFold(token, "returning") || strings.EqualFold(token, "output") {
+			returning = true
+			break
+		}
+	}
+
+	switch kind {
+	case "WITH":
+		// общие табличные выражения используются в запросах любого вида
+	case "SELECT", "VALUES", "SHOW", "EXPLAIN", "PRAGMA", "DESCRIBE", "TABLE":
+		if q.Type != TypeMany && q.Type != TypeOne {
+			add(SeverityWarning, CodeStatementKind,
+				"%s statement returns rows, but query type is %v", kind, q.Type)
+		}
+	default:
+		if (q.Type == TypeMany || q.Type == TypeOne) && !returning {
+			add(SeverityWarning, CodeStatementKind,
+				"%s statement does not return rows, but query type is %v", kind, q.Type)
+		}
+	}
+
+	return errs
+}
+
+// paramsCount возвращает количество параметров, используемых в запросе: наибольший номер
+// параметра, как его определяет драйвер базы данных. Учитываются только параметры, которые
+// поддерживает диалект: в PostgreSQL — нумерованные ($1), в MySQL — позиционные (?),
+// а в SQLite и для не заданного диалекта — позиционные, которые получают следующий номер,
+// нумерованные ($1 и ?1) и именованные (:name, @name и $name), которые получают следующий
+// номер при первом использовании, а при повторном используют тот же.
+func paramsCount(tokens []string, dialect Dialect) int {
+	var count int
+	named := make(map[string]bool)
+	for _, token := range tokens {
+		switch {
+		case token == "?" && dialect != DialectPostgres:
+			count++
+		case numberedParam(token) && dialect != DialectMySQL &&
+			(token[0] == '$' || dialect != DialectPostgres):
+			if n, err := strconv.Atoi(token[1:]); err == nil {
+				count = max(count, n)
+			}
+		case namedParam(token) && (dialect == DialectSQLite || dialect == DialectAny):
+			if !named[token] {
+				named[token] = true
+				count++
+			}
+		}
+	}
+
+	return count
+}
+
+// numberedParam возвращает true, если токен является нумерованным параметром ($1 или ?1).
+func numberedParam(token string) bool {
+	if len(token) < 2 || (token[0] != '$' && token[0] != '?') {
+		return false
+	}
+
+	for _, r := range token[1:] {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// namedParam возвращает true, если токен является именованным параметром (:name, @name
+// или $name).
+func namedParam(token string) bool {
+	return len(token) > 1 && strings.ContainsRune(":@$", rune(token[0])) && !numberedParam(token)
+}
+
+// placeholders возвращает, используются ли в запросе позиционные (?) и нумерованные ($1)
+// параметры.
+func placeholders(tokens []string) (positional, numbered bool) {
+	for _, token := range tokens {
+		switch {
+		case token == "?" || numberedParam(token) && token[0] == '?':
+			positional = true
+		case numberedParam(token):
+			numbered = true
+		}
+	}
+
+	return positional, numbered
+}
+
+// sqlTokens разбивает текст SQL запроса на слова, параметры и символы, пропуская комментарии,
+// строки и идентификаторы в кавычках.
+func sqlTokens(s string) []string {
+	var tokens []string
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			// однострочный комментарий
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			// многострочный комментарий
+			i += 2
+			for i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/') {
+				i++
+			}
+			i++
+
+		case r == '\'' || r == '"' || r == '`':
+			// строка или идентификатор в кавычках
+			start := i
+			for i++; i < len(runes) && runes[i] != r; i++ {
+			}
+			tokens = append(tokens, string(runes[start:min(i+1, len(runes))]))
+
+		case (r == '$' || r == '?') && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			// нумерованный параметр
+			start := i
+			for i++; i+1 < len(runes) && unicode.IsDigit(runes[i+1]); i++ {
+			}
+			tokens = append(tokens, string(runes[start:i+1]))
+
+		case (r == ':' || r == '@' || r == '$') && i+1 < len(runes) &&
+			(unicode.IsLetter(runes[i+1]) || runes[i+1] == '_') && (i == 0 || runes[i-1] != ':'):
+			// именованный параметр; приведение типа PostgreSQL (::text) параметром не является
+			start := i
+			for ; i+1 < len(runes) && (unicode.In(runes[i+1], unicode.Letter, unicode.Digit) || runes[i+1] == '_'); i++ {
+			}
+			tokens = append(tokens, string(runes[start:i+1]))
+
+		case unicode.IsLetter(r) || r == '_' || unicode.IsDigit(r):
+			start := i
+			for ; i+1 < len(runes) && (unicode.In(runes[i+1], unicode.Letter, unicode.Digit) || runes[i+1] == '_'); i++ {
+			}
+			tokens = append(tokens, string(runes[start:i+1]))
+
+		case r == '(':
+			// открывающая скобка в начале запроса не влияет на его вид
+			if len(tokens) > 0 {
+				tokens = append(tokens, "(")
+			}
+
+		default:
+			tokens = append(tokens, string(r))
+		}
+	}
+
+	return tokens
+}
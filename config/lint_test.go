@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLintParams(t *testing.T) {
+	for _, tt := range []struct {
+		dialect Dialect
+		sql     string
+		in      int
+		want    []string // коды проблем с уровнем важности
+	}{
+		{DialectPostgres, "select id from items where data ? 'tag' and id = $1", 1, nil},
+		{DialectPostgres, "select id from items where data ?| array['a'] and id = $1", 1, nil},
+		{DialectPostgres, "select id from items where id = ?", 1, []string{"error SG014", "warning SG017"}},
+		{DialectPostgres, "select id from items where id = $1 or parent = $1", 1, nil},
+		{DialectPostgres, "select id from items where id = @id", 1, []string{"error SG014"}},
+		{DialectMySQL, "select id from items where id = ? and kind = ?", 2, nil},
+		{DialectMySQL, "select id from items where id = $1", 1, []string{"error SG014", "warning SG017"}},
+		{DialectMySQL, "select @total := count(*) from items where kind = ?", 1, nil},
+		{DialectSQLite, "select id from items where id = :id or parent = :id", 1, nil},
+		{DialectSQLite, "select id from items where id = ?1 and kind = ?", 2, nil},
+		{DialectAny, "select id from items where id = ?", 1, nil},
+		{DialectAny, "select id from items where id = ? and kind = ?", 1, []string{"warning SG014"}},
+		{DialectAny, "select id from items where id = @id", 0, []string{"warning SG014"}},
+	} {
+		name := fmt.Sprintf("%s %s", tt.dialect, tt.sql)
+		var in strings.Builder
+		if tt.in > 0 {
+			in.WriteString("  in:\n")
+			for i := range tt.in {
+				fmt.Fprintf(&in, "    p%d: int64\n", i)
+			}
+		}
+
+		qs, err := ParseBytes("items.yaml", []byte("items:\n  type: many\n  sql: "+tt.sql+
+			"\n"+in.String()+"  out:\n    id: int64\n"))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		var got []string
+		for _, problem := range qs.LintDialect(tt.dialect) {
+			got = append(got, problem.Severity.String()+" "+string(problem.Code))
+		}
+
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: problems = %v, want %v", name, got, tt.want)
+		}
+	}
+}
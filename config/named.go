@@ -0,0 +1,289 @@
+package config
+
+import (
+	"go/ast"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TypesKey задаёт название раздела файла с описанием запросов, в котором вместо запроса
+// описываются именованные типы.
+const TypesKey = "types"
+
+// NamedType описывает именованный тип, который объявляется в сгенерированной библиотеке
+// и используется в описании полей запросов по названию без префикса пакета.
+type NamedType struct {
+	Name     string      // название типа golang
+	Type     string      // базовый тип
+	Scan     bool        // генерировать методы Scan и Value
+	Values   []EnumValue // допустимые значения перечисления
+	Comment  Comment     // комментарий
+	position `yaml:"-"`  // позиция в исходном файле
+}
+
+// EnumValue описывает допустимое значение перечисления.
+type EnumValue struct {
+	Name     string     // название значения
+	Value    string     // значение: строка для строковых и число для целочисленных типов
+	Comment  Comment    // комментарий
+	position `yaml:"-"` // позиция в исходном файле
+}
+
+// NamedTypes описывает список именованных типов.
+type NamedTypes []NamedType
+
+// UnmarshalYAML реализует интерфейс [yaml.Unmarshaler].
+// Возвращает список всех найденных в описании типов ошибок [Errors].
+func (ts *NamedTypes) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias // подставляем оригинальные данные для разбора
+	}
+
+	if n.Kind != yaml.MappingNode {
+		return NewError(CodeStructure, nil, n, "types must be a YAML mapping: have %v", kindName(n.Kind))
+	}
+
+	*ts = make(NamedTypes, 0, len(n.Content)/2)
+	names := make(map[string]bool, len(n.Content)/2)
+
+	var errs Errors
+	for i := 1; i < len(n.Content); i += 2 {
+		nameNode, valueNode := n.Content[i-1], n.Content[i]
+
+		t, err := parseNamedType(nameNode, valueNode)
+		if err != nil {
+			errs.Add(err)
+			continue
+		}
+
+		// проверяем, что такой тип ещё не был определён ранее
+		if names[t.Name] {
+			errs.Add(NewError(CodeRedefined, nil, nameNode, "type %q redefined", t.Name))
+			continue
+		}
+
+		names[t.Name] = true
+		*ts = append(*ts, t)
+	}
+
+	return errs.Err()
+}
+
+// parseNamedType разбирает описание именованного типа.
+func parseNamedType(nameNode, valueNode *yaml.Node) (NamedType, error) {
+	t := NamedType{
+		Name:     nameNode.Value,
+		position: parseSource(nameNode),
+	}
+
+	if err := checkGoName(t.Name); err != nil {
+		return t, NewError(CodeGoName, err, nameNode, "invalid type name")
+	}
+
+	// разбираем поля с описанием типа
+	var (
+		errs       Errors
+		typeNode   = valueNode
+		valuesNode *yaml.Node // нода со значениями перечисления
+	)
+
+	switch valueNode.Kind {
+	case yaml.ScalarNode:
+		// краткая форма описания типа: "Name: type"
+
+	case yaml.MappingNode:
+		// полная форма описания типа с дополнительными свойствами
+		typeNode = nil
+		for j := 1; j < len(valueNode.Content); j += 2 {
+			propNode, propValueNode := valueNode.Content[j-1], valueNode.Content[j]
+			switch propNode.Value {
+			case "type":
+				typeNode = propValueNode
+			case "scan":
+				if err := propValueNode.Decode(&t.Scan); err != nil {
+					errs.Add(NewError(CodeStructure, nil, propValueNode,
+						"type %q scan must be a boolean: have %q", t.Name, propValueNode.Value))
+				}
+			case "values":
+				valuesNode = propValueNode
+			default:
+				errs.Add(NewError(CodeUnknownProperty, nil, propNode,
+					"unknown type %q property %q", t.Name, propNode.Value))
+			}
+		}
+
+		if typeNode == nil {
+			errs.Add(NewError(CodeUndefined, nil, nameNode, "type %q underlying type not defined", t.Name))
+			return t, errs
+		}
+
+	default:
+		return t, NewError(CodeStructure, nil, valueNode,
+			"type %q must be a type name or a YAML mapping: have %v", t.Name, kindName(valueNode.Kind))
+	}
+
+	// базовый тип
+	t.Type = typeNode.Value
+	if t.Type == "" {
+		errs.Add(NewError(CodeUndefined, nil, typeNode, "type %q underlying type not defined", t.Name))
+		return t, errs
+	}
+
+	switch _, err := ParseType(t.Type); {
+	case err != nil:
+		errs.Add(NewError(CodeFieldType, err, typeNode, "type %q underlying type", t.Name))
+	case valuesNode != nil:
+		// значения перечисления зависят от базового типа
+		values, err := parseEnumValues(t, valuesNode)
+		errs.Add(err)
+		t.Values = values
+	case t.Scan && t.ValueType() == "":
+		errs.Add(NewError(CodeFieldType, nil, typeNode,
+			"type %q: scan is supported only for string, integer, float and boolean types", t.Name))
+	}
+
+	// комментарий
+	t.Comment = parseComments(nameNode, valueNode)
+
+	return t, errs.Err()
+}
+
+// parseEnumValues разбирает описание допустимых значений перечисления t. Значения задаются
+// по названиям; для строковых перечислений значение можно не указывать, и тогда оно совпадает
+// с названием.
+func parseEnumValues(t NamedType, n *yaml.Node) ([]EnumValue, error) {
+	if n.Kind != yaml.MappingNode || len(n.Content) == 0 {
+		return nil, NewError(CodeStructure, nil, n, "type %q values must be a non-empty YAML mapping", t.Name)
+	}
+
+	valueType := t.ValueType()
+	if valueType != "string" && valueType != "int64" {
+		return nil, NewError(CodeFieldType, nil, n,
+			"type %q: values are supported only for string and integer types", t.Name)
+	}
+
+	var errs Errors
+	values := make([]EnumValue, 0, len(n.Content)/2)
+	seen := make(map[string]string, len(n.Content)/2) // значение -> название
+	for i := 1; i < len(n.Content); i += 2 {
+		nameNode, valueNode := n.Content[i-1], n.Content[i]
+		v := EnumValue{
+			Name:     nameNode.Value,
+			Value:    valueNode.Value,
+			Comment:  parseComments(nameNode, valueNode),
+			position: parseSource(nameNode),
+		}
+
+		switch {
+		case v.Name == "":
+			errs.Add(NewError(CodeUndefined, nil, nameNode, "type %q value name not defined", t.Name))
+			continue
+		case valueNode.Kind != yaml.ScalarNode:
+			errs.Add(NewError(CodeStructure, nil, valueNode,
+				"type %q value %q must be a scalar: have %v", t.Name, v.Name, kindName(valueNode.Kind)))
+			continue
+		case valueNode.Tag == "!!null" && valueType == "string":
+			v.Value = v.Name // значение совпадает с названием
+		case valueNode.Tag == "!!null":
+			errs.Add(NewError(CodeUndefined, nil, nameNode, "type %q value %q not defined", t.Name, v.Name))
+			continue
+		}
+
+		// числовые значения сравниваются по значению, а не по записи: "1" и "0x1" совпадают
+		key := v.Value
+		if valueType == "int64" {
+			n, err := parseInteger(v.Value, t.basicType())
+			if err != nil {
+				errs.Add(NewError(CodeFieldType, nil, valueNode,
+					"type %q value %q must be a valid %s: have %q", t.Name, v.Name, t.Type, v.Value))
+				continue
+			}
+
+			key = n
+		}
+
+		if other, ok := seen[key]; ok {
+			errs.Add(NewError(CodeRedefined, nil, nameNode,
+				"type %q value %q of %q already used by %q", t.Name, v.Value, v.Name, other))
+			continue
+		}
+
+		seen[key] = v.Name
+		values = append(values, v)
+	}
+
+	return values, errs.Err()
+}
+
+// parseInteger разбирает целое число с учётом размера и знака целочисленного типа typ
+// и возвращает его десятичную запись.
+func parseInteger(value, typ string) (string, error) {
+	bitSize := 64
+	switch typ {
+	case "int8", "uint8", "byte":
+		bitSize = 8
+	case "int16", "uint16":
+		bitSize = 16
+	case "int32", "uint32", "rune":
+		bitSize = 32
+	}
+
+	switch typ {
+	case "uint", "uint8", "uint16", "uint32", "uint64", "byte":
+		n, err := strconv.ParseUint(value, 0, bitSize)
+		return strconv.FormatUint(n, 10), err
+	default:
+		n, err := strconv.ParseInt(value, 0, bitSize)
+		return strconv.FormatInt(n, 10), err
+	}
+}
+
+// IsEnum возвращает true, если тип является перечислением с заданным списком значений.
+func (t NamedType) IsEnum() bool {
+	return len(t.Values) > 0
+}
+
+// ValueType возвращает тип значения базы данных (driver.Value), в который преобразуется
+// значение базового типа: "string", "int64", "float64" или "bool". Для остальных базовых
+// типов возвращается пустая строка.
+func (t NamedType) ValueType() string {
+	switch t.basicType() {
+	case "string":
+		return "string"
+	case "int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64", "byte", "rune":
+		return "int64"
+	case "float32", "float64":
+		return "float64"
+	case "bool":
+		return "bool"
+	default:
+		return ""
+	}
+}
+
+// basicType возвращает название базового типа, заданного идентификатором, или пустую строку
+// для остальных описаний типа.
+func (t NamedType) basicType() string {
+	expr, err := ParseType(t.Type)
+	if err != nil {
+		return ""
+	}
+
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+
+	return ""
+}
+
+// setSource сохраняет информацию об исходном файле в позициях описаний типов.
+func (ts NamedTypes) setSource(src *source) {
+	for i := range ts {
+		ts[i].position.src = src
+		for j := range ts[i].Values {
+			ts[i].Values[j].position.src = src
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// parseTypes разбирает раздел с описанием именованных типов и возвращает их вместе
+// с кодами и сообщениями найденных ошибок.
+func parseTypes(t *testing.T, data string) (NamedTypes, []string) {
+	t.Helper()
+
+	qs, err := ParseBytes("types.yaml", []byte("types:"+data))
+	if err == nil {
+		return qs.Types, nil
+	}
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("unexpected error type %T: %v", err, err)
+	}
+
+	list := make([]string, 0, len(errs))
+	for _, err := range errs {
+		list = append(list, fmt.Sprintf("%d %s %s", err.Line, err.Code, err.Message))
+	}
+
+	return nil, list
+}
+
+func TestParseEnumValues(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		data   string
+		values []string // название=значение
+		errs   []string // строка, код и сообщение ошибки
+	}{{
+		name: "string",
+		data: `
+  Status:
+    type: string
+    values:
+      active:
+      in progress: progress
+`,
+		values: []string{"active=active", "in progress=progress"},
+	}, {
+		name: "integer",
+		data: `
+  Level:
+    type: int8
+    values:
+      low: 1
+      high: 0x7f
+      negative: -128
+`,
+		values: []string{"low=1", "high=0x7f", "negative=-128"},
+	}, {
+		name: "unsigned",
+		data: `
+  Flags:
+    type: uint16
+    values:
+      all: 65535
+`,
+		values: []string{"all=65535"},
+	}, {
+		name: "overflow",
+		data: `
+  Level:
+    type: int8
+    values:
+      low: 1
+      high: 128
+`,
+		errs: []string{`6 SG008 type "Level" value "high" must be a valid int8: have "128"`},
+	}, {
+		name: "negative unsigned",
+		data: `
+  Flags:
+    type: uint
+    values:
+      none: -1
+`,
+		errs: []string{`5 SG008 type "Flags" value "none" must be a valid uint: have "-1"`},
+	}, {
+		name: "not a number",
+		data: `
+  Level:
+    type: int
+    values:
+      low: one
+`,
+		errs: []string{`5 SG008 type "Level" value "low" must be a valid int: have "one"`},
+	}, {
+		name: "integer without value",
+		data: `
+  Level:
+    type: int
+    values:
+      low:
+`,
+		errs: []string{`5 SG007 type "Level" value "low" not defined`},
+	}, {
+		name: "duplicate string",
+		data: `
+  Status:
+    type: string
+    values:
+      active:
+      enabled: active
+`,
+		errs: []string{`6 SG003 type "Status" value "active" of "enabled" already used by "active"`},
+	}, {
+		name: "duplicate integer",
+		data: `
+  Level:
+    type: int
+    values:
+      low: 1
+      first: 0x01
+`,
+		errs: []string{`6 SG003 type "Level" value "0x01" of "first" already used by "low"`},
+	}, {
+		name: "float base type",
+		data: `
+  Ratio:
+    type: float64
+    values:
+      half: 0.5
+`,
+		errs: []string{`5 SG008 type "Ratio": values are supported only for string and integer types`},
+	}, {
+		name: "composite base type",
+		data: `
+  Tags:
+    type: '[]string'
+    values:
+      empty:
+`,
+		errs: []string{`5 SG008 type "Tags": values are supported only for string and integer types`},
+	}, {
+		name: "invalid base type",
+		data: `
+  Broken:
+    type: map[string
+    values:
+      empty:
+`,
+		errs: []string{`3 SG008 type "Broken" underlying type`},
+	}, {
+		name: "empty values",
+		data: `
+  Status:
+    type: string
+    values: {}
+`,
+		errs: []string{`4 SG002 type "Status" values must be a non-empty YAML mapping`},
+	}, {
+		name: "values not a mapping",
+		data: `
+  Status:
+    type: string
+    values: [active]
+`,
+		errs: []string{`4 SG002 type "Status" values must be a non-empty YAML mapping`},
+	}} {
+		types, errs := parseTypes(t, tt.data)
+		if !slices.Equal(errs, tt.errs) {
+			t.Errorf("%s: errors = %q, want %q", tt.name, errs, tt.errs)
+			continue
+		}
+
+		if tt.errs != nil {
+			continue
+		}
+
+		var values []string
+		for _, v := range types[0].Values {
+			values = append(values, v.Name+"="+v.Value)
+		}
+
+		if !slices.Equal(values, tt.values) {
+			t.Errorf("%s: values = %q, want %q", tt.name, values, tt.values)
+		}
+	}
+}
+
+func TestParseInteger(t *testing.T) {
+	for _, tt := range []struct {
+		value, typ string
+		want       string
+		ok         bool
+	}{
+		{"42", "int", "42", true},
+		{"0x10", "int64", "16", true},
+		{"0o17", "int32", "15", true},
+		{"0b101", "uint8", "5", true},
+		{"1_000", "int", "1000", true},
+		{"127", "int8", "127", true},
+		{"128", "int8", "", false},
+		{"-129", "int8", "", false},
+		{"255", "byte", "255", true},
+		{"256", "uint8", "", false},
+		{"2147483648", "rune", "", false},
+		{"65536", "uint16", "", false},
+		{"-1", "uint64", "", false},
+		{"18446744073709551615", "uint64", "18446744073709551615", true},
+		{"9223372036854775808", "int64", "", false},
+		{"1.5", "int", "", false},
+		{"", "int", "", false},
+	} {
+		got, err := parseInteger(tt.value, tt.typ)
+		if (err == nil) != tt.ok || (tt.ok && got != tt.want) {
+			t.Errorf("parseInteger(%q, %q) = %q, %v; want %q, ok %v", tt.value, tt.typ, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNamedTypeBasicType(t *testing.T) {
+	for _, tt := range []struct {
+		typ             string
+		basic, valueTyp string
+	}{
+		{"string", "string", "string"},
+		{"int", "int", "int64"},
+		{"uint8", "uint8", "int64"},
+		{"rune", "rune", "int64"},
+		{"float32", "float32", "float64"},
+		{"bool", "bool", "bool"},
+		{"UserID", "UserID", ""},
+		{"time.Time", "", ""},
+		{"[]byte", "", ""},
+		{"*string", "", ""},
+		{"map[string", "", ""},
+		{"", "", ""},
+	} {
+		nt := NamedType{Name: "T", Type: tt.typ}
+		if got := nt.basicType(); got != tt.basic {
+			t.Errorf("basicType(%q) = %q, want %q", tt.typ, got, tt.basic)
+		}
+
+		if got := nt.ValueType(); got != tt.valueTyp {
+			t.Errorf("ValueType(%q) = %q, want %q", tt.typ, got, tt.valueTyp)
+		}
+	}
+}
@@ -2,26 +2,120 @@ package config
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"regexp"
+	"strconv"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Parse разбирает файл с описанием запросов и возвращает разобранный результат.
+// В случае ошибок в описании возвращает список всех найденных ошибок [Errors]
+// с указанием имени файла, позиции и фрагмента исходного текста.
 func Parse(filename string) (*Queries, error) {
-	file, err := os.Open(filename)
+	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("open %q: %w", filename, err)
 	}
-	defer file.Close()
 
-	dec := yaml.NewDecoder(file)
-	dec.KnownFields(true)
+	return ParseBytes(filename, data)
+}
+
+// ParseReader читает и разбирает описание запросов из r. Имя файла filename используется
+// только в описании ошибок.
+func ParseReader(filename string, r io.Reader) (*Queries, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read %q: %w", filename, err)
+	}
+
+	return ParseBytes(filename, data)
+}
+
+// ParseBytes разбирает описание запросов из data и возвращает разобранный результат.
+// Имя файла filename используется только в описании ошибок.
+func ParseBytes(filename string, data []byte) (*Queries, error) {
+	src := newSource(filename, data)
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, src.errors(syntaxError(err))
+	}
+
+	if len(root.Content) == 0 {
+		return nil, src.errors(Error{Code: CodeStructure, Message: "queries not defined"})
+	}
 
 	var q Queries
-	if err := dec.Decode(&q); err != nil {
-		return nil, fmt.Errorf("parse: %w", err)
+	if err := q.UnmarshalYAML(root.Content[0]); err != nil {
+		return nil, src.errors(err)
 	}
 
+	q.setSource(src)
+
 	return &q, nil
 }
+
+// reSyntaxError разбирает описание синтаксической ошибки YAML с номером строки.
+var reSyntaxError = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// syntaxError возвращает описание синтаксической ошибки YAML.
+func syntaxError(err error) Error {
+	qerr := Error{Code: CodeSyntax, Message: err.Error()}
+	if m := reSyntaxError.FindStringSubmatch(err.Error()); m != nil {
+		qerr.Line, _ = strconv.Atoi(m[1])
+		qerr.Message = m[2]
+	}
+
+	return qerr
+}
+
+// errors возвращает список ошибок с информацией об исходном файле.
+// Повторяющиеся ошибки (например, в полях, определённых через ссылки) удаляются.
+func (s *source) errors(err error) Errors {
+	var list Errors
+	list.Add(err)
+
+	type key struct {
+		code         Code
+		line, column int
+		message      string
+	}
+
+	seen := make(map[key]bool, len(list))
+	result := list[:0]
+	for _, qerr := range list {
+		k := key{qerr.Code, qerr.Line, qerr.Column, qerr.Text()}
+		if seen[k] {
+			continue
+		}
+
+		seen[k] = true
+		if qerr.File == "" {
+			qerr.File = s.name
+			qerr.Snippet = s.line(qerr.Line)
+		}
+
+		result = append(result, qerr)
+	}
+
+	return result
+}
+
+// setSource сохраняет информацию об исходном файле в позициях описаний запросов,
+// чтобы использовать её при формировании ошибок на следующих этапах.
+func (qs *Queries) setSource(src *source) {
+	qs.Types.setSource(src)
+	for i := range qs.Queries {
+		q := &qs.Queries[i]
+		q.position.src = src
+		q.SQL.position.src = src
+		for _, fs := range [...]*Fields{&q.In, &q.Out} {
+			fs.position.src = src
+			for j := range fs.Fields {
+				fs.Fields[j].position.src = src
+			}
+		}
+	}
+}
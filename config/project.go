@@ -0,0 +1,153 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFile содержит название файла с настройками проекта по умолчанию.
+const ProjectFile = "sqlgen.yaml"
+
+// Project описывает настройки проекта.
+type Project struct {
+	// Initialisms содержит дополнительный список аббревиатур, которые в сгенерированных
+	// названиях записываются заглавными буквами.
+	Initialisms []string `yaml:"initialisms"`
+	// Targets описывает цели генерации: библиотеки, которые генерируются за один запуск.
+	// Если цели не заданы, то генерируется одна библиотека по аргументам команды.
+	Targets []Target `yaml:"targets"`
+	// SQLTypes задаёт соответствие типов колонок SQL типам golang, которое используется
+	// при определении исходящих параметров и проверке запросов по структуре базы данных.
+	// Пакеты типов golang импортируются автоматически.
+	SQLTypes []SQLType `yaml:"sql_types"`
+	// Types описывает именованные типы, которые объявляются в основном файле каждой
+	// сгенерированной библиотеки и доступны в описании запросов из всех файлов.
+	Types NamedTypes `yaml:"types"`
+}
+
+// SQLType описывает соответствие типа колонки SQL типу golang. Тип golang задаётся с полным
+// путём импорта пакета ("github.com/google/uuid.UUID") или с префиксом пакета, который
+// поддерживается генератором ("json.RawMessage").
+type SQLType struct {
+	SQL      string  `yaml:"sql"`      // название типа SQL без размера и точности
+	Go       string  `yaml:"go"`       // тип golang
+	Nullable string  `yaml:"nullable"` // тип golang для колонок, которые могут содержать NULL
+	Dialect  Dialect `yaml:"dialect"`  // диалект SQL, для которого используется соответствие
+}
+
+// Target описывает цель генерации: библиотеку, которая генерируется из набора файлов
+// с описанием запросов. Относительные пути задаются относительно файла с настройками проекта.
+type Target struct {
+	Name        string   `yaml:"name"`        // название цели (по умолчанию — каталог для записи)
+	Sources     []string `yaml:"sources"`     // файлы, каталоги или маски файлов с описанием запросов
+	Out         string   `yaml:"out"`         // каталог для записи сгенерированных файлов
+	Package     string   `yaml:"package"`     // название пакета (по умолчанию — название каталога)
+	Imports     []string `yaml:"imports"`     // дополнительные пакеты для импорта
+	Dialect     Dialect  `yaml:"dialect"`     // диалект SQL
+	Initialisms []string `yaml:"initialisms"` // аббревиатуры в дополнение к общим для проекта
+	Templates   string   `yaml:"templates"`   // каталог с пользовательскими шаблонами
+	Tests       bool     `yaml:"tests"`       // генерировать тесты запросов
+	Schema      []string `yaml:"schema"`      // файлы с описанием структуры базы данных (DDL)
+}
+
+// ParseProject разбирает файл с настройками проекта.
+// Если файл не существует, то возвращает пустые настройки и ошибку [os.ErrNotExist].
+func ParseProject(filename string) (*Project, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return new(Project), err
+		}
+
+		return nil, fmt.Errorf("open %q: %w", filename, err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	src := newSource(filename, data)
+
+	var p Project
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		// ошибки в описании типов содержат позицию в файле
+		var qerr Error
+		if errors.As(err, &qerr) {
+			err = src.errors(err)
+		}
+
+		return nil, fmt.Errorf("parse project %q: %w", filename, err)
+	}
+
+	p.Types.setSource(src)
+
+	if err := p.setTargets(filepath.Dir(filename)); err != nil {
+		return nil, fmt.Errorf("parse project %q: %w", filename, err)
+	}
+
+	for i, t := range p.SQLTypes {
+		if t.SQL == "" || t.Go == "" {
+			return nil, fmt.Errorf("parse project %q: sql type #%d: sql and go types must be set", filename, i+1)
+		}
+	}
+
+	return &p, nil
+}
+
+// setTargets проверяет описание целей генерации, задаёт значения по умолчанию и
+// преобразует относительные пути в пути относительно каталога dir.
+func (p *Project) setTargets(dir string) error {
+	names := make(map[string]bool, len(p.Targets))
+	outs := make(map[string]string, len(p.Targets))
+	for i := range p.Targets {
+		t := &p.Targets[i]
+		if len(t.Sources) == 0 {
+			return fmt.Errorf("target #%d: sources not defined", i+1)
+		}
+
+		if t.Name == "" {
+			t.Name = filepath.ToSlash(filepath.Clean(t.Out))
+		}
+
+		for j, source := range t.Sources {
+			t.Sources[j] = projectPath(dir, source)
+		}
+
+		for j, name := range t.Schema {
+			t.Schema[j] = projectPath(dir, name)
+		}
+
+		t.Out = projectPath(dir, t.Out)
+		if t.Templates != "" {
+			t.Templates = projectPath(dir, t.Templates)
+		}
+
+		if names[t.Name] {
+			return fmt.Errorf("target %q redefined", t.Name)
+		}
+
+		names[t.Name] = true
+
+		if other, ok := outs[t.Out]; ok {
+			return fmt.Errorf("target %q: output folder %q already used by target %q", t.Name, t.Out, other)
+		}
+
+		outs[t.Out] = t.Name
+	}
+
+	return nil
+}
+
+// projectPath возвращает путь относительно каталога dir, если путь не абсолютный.
+func projectPath(dir, name string) string {
+	if filepath.IsAbs(name) {
+		return filepath.Clean(name)
+	}
+
+	return filepath.Join(dir, name)
+}
@@ -7,6 +7,7 @@ import (
 // Queries содержит список описаний запросов.
 type Queries struct {
 	Queries []Query        // список запросов
+	Types   NamedTypes     // именованные типы из раздела [TypesKey]
 	index   map[string]int // индекс запросов по их заголовку
 }
 
@@ -39,9 +40,10 @@ func (qs Queries) Get(name string) *Query {
 }
 
 // UnmarshalYAML реализует интерфейс [yaml.Unmarshaler].
+// Возвращает список всех найденных в описании запросов ошибок [Errors].
 func (qs *Queries) UnmarshalYAML(n *yaml.Node) error {
 	if n.Kind != yaml.MappingNode {
-		return NewError(nil, n, "query must be a YAML mapping: have %v", n.Kind)
+		return NewError(CodeStructure, nil, n, "queries must be a YAML mapping: have %v", kindName(n.Kind))
 	}
 
 	// инициализируем список запросов и индекс
@@ -50,32 +52,51 @@ func (qs *Queries) UnmarshalYAML(n *yaml.Node) error {
 	qs.index = make(map[string]int, count)
 
 	// разбираем дерево с описанием запросов в формате YAML
+	var errs Errors
 	for i := 1; i < len(n.Content); i += 2 {
 		var q Query
 
 		nameNode := n.Content[i-1] // информация о названии
 		q.Name = nameNode.Value    // сохраняем название запроса
 
+		// раздел с описанием именованных типов вместо запроса
+		if q.Name == TypesKey {
+			errs.Add(qs.Types.UnmarshalYAML(n.Content[i]))
+			continue
+		}
+
 		// проверяем, что имя запроса уникально и ещё не использовалось
 		if _, ok := qs.index[q.Name]; ok {
-			return NewError(nil, n, "query redefined")
+			errs.Add(NewError(CodeRedefined, nil, nameNode, "query %q redefined", q.Name))
+			continue
 		}
 
-		// комментарий
-		q.Comment = parseComments(nameNode)
-
 		// заполняем информацию о запросе
-		if err := n.Content[i].Decode(&q); err != nil {
-			return NewError(err, n, "query decode")
+		var qerrs Errors
+		valueNode := n.Content[i]
+		if valueNode.Kind == yaml.AliasNode {
+			valueNode = valueNode.Alias // подставляем оригинальные данные для разбора
+		}
+
+		qerrs.Add(q.UnmarshalYAML(valueNode))
+		// все ошибки относятся к текущему запросу
+		for j := range qerrs {
+			qerrs[j].Query = q.Name
 		}
 
+		errs = append(errs, qerrs...)
+
+		// комментарий и позиция соответствуют названию запроса
+		q.Comment = parseComments(nameNode)
+		q.position = parseSource(nameNode)
+
 		// сохраняем разобранный запрос и его индекс
 		qs.Queries = append(qs.Queries, q)
 		idx := len(qs.Queries) - 1
 		qs.index[q.Name] = idx
 	}
 
-	return nil
+	return errs.Err()
 }
 
 // // MarshalYAML поддерживает интерфейс [yaml.Marshaler].
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"go/ast"
+
 	"gopkg.in/yaml.v3"
 )
 
@@ -11,6 +13,7 @@ import (
 // как должно было бы быть по правилам.
 type Query struct {
 	Name     string     // название
+	GoName   string     // явно заданное название функции в golang
 	Comment  Comment    // комментарий
 	Type     Type       // тип запроса
 	SQL      SQL        // текст с SQL запросом
@@ -20,67 +23,130 @@ type Query struct {
 }
 
 // UnmarshalYAML реализует интерфейс [yaml.Unmarshaler].
+// Возвращает список всех найденных в описании запроса ошибок [Errors].
 func (q *Query) UnmarshalYAML(n *yaml.Node) error {
 	// запоминаем позицию с описанием запроса в исходном файле
 	q.position = parseSource(n)
 
+	if n.Kind != yaml.MappingNode {
+		return NewError(CodeStructure, nil, n, "query must be a YAML mapping: have %v", kindName(n.Kind))
+	}
+
+	var (
+		errs       Errors
+		typeNode   = n        // нода с описанием типа запроса
+		outNode    *yaml.Node // нода с названием исходящих параметров
+		outFailed  bool       // ошибка разбора исходящих параметров
+		typeFailed bool       // ошибка разбора типа запроса
+	)
+
 	for i := 1; i < len(n.Content); i += 2 {
 		nameNode, valueNode := n.Content[i-1], n.Content[i]
 
 		// заполняем значения конкретных полей
 		switch nameNode.Value {
 		case "type":
+			typeNode = valueNode
 			if err := q.Type.UnmarshalYAML(valueNode); err != nil {
-				return NewError(err, valueNode, "parse type")
+				typeFailed = true
+				errs.Add(err)
 			}
 
-		case "sql":
-			if err := q.SQL.UnmarshalYAML(valueNode); err != nil {
-				return NewError(err, valueNode, "parse sql")
+		case "go_name":
+			q.GoName = valueNode.Value
+			if err := checkGoName(q.GoName); err != nil {
+				errs.Add(NewError(CodeGoName, err, valueNode, "invalid go_name"))
 			}
 
+		case "sql":
+			errs.Add(q.SQL.UnmarshalYAML(valueNode))
+
 		case "in":
-			if err := q.In.UnmarshalYAML(valueNode); err != nil {
-				return NewError(err, valueNode, "parse in params")
-			}
+			errs.Add(q.In.UnmarshalYAML(valueNode))
 
 			// добавляем комментарий, который задан на уровне названия
 			q.In.Comment = parseComments(nameNode)
 
 		case "out":
+			outNode = nameNode
 			if err := q.Out.UnmarshalYAML(valueNode); err != nil {
-				return NewError(err, valueNode, "parse out params")
+				outFailed = true
+				errs.Add(err)
 			}
 
 			// добавляем комментарий, который задан на уровне названия
 			q.Out.Comment = parseComments(nameNode)
 
 		default:
-			return NewError(nil, nameNode, "unknown property %q", nameNode.Value)
+			errs.Add(NewError(CodeUnknownProperty, nil, nameNode, "unknown property %q", nameNode.Value))
 		}
 	}
 
-	// дополнительные проверки по заполненности полей запросов
+	// дополнительные проверки по заполненности полей запросов имеют смысл,
+	// только если тип запроса определён корректно
+	if typeFailed {
+		return errs.Err()
+	}
+
 	switch q.Type {
 	case TypeMany, TypeOne:
+		// если параметры разбора ответа не заданы, то они определяются по структуре базы данных
+		if outNode == nil {
+			q.Out.position = q.position
+			q.Out.Auto = true
+		}
+
 		// для запросов, которые возвращают данные, должны быть описаны параметры разбора ответа
-		if len(q.Out.Fields) == 0 {
-			return NewError(nil, n, "parameters for outgoing data are not described")
+		if len(q.Out.Fields) == 0 && !q.Out.Auto && !outFailed {
+			node := outNode
+			if node == nil {
+				node = typeNode
+			}
+
+			errs.Add(NewError(CodeOutParams, nil, node,
+				"parameters for outgoing data are not described for query type %v", q.Type))
 		}
 
-		// проверяем, что исходящие параметры не определены как ссылки
+		// проверяем корректность описания указателей в исходящих параметрах:
+		// указатель (*T) разрешён и при значении NULL в ответе остаётся nil,
+		// а взятие адреса (&T) и указатель на указатель (**T) не поддерживаются
 		for _, field := range q.Out.Fields {
-			if field.Type[0] == '*' || field.Type[0] == '&' {
-				return NewError(nil, n, "unsupported field %q type pointer", field.Name)
+			if field.Type[0] == '&' || pointerDepth(field.Type) > 1 {
+				errs.Add(field.position.error(CodePointer, nil,
+					"unsupported field %q type pointer %q", field.Name, field.Type))
 			}
 		}
 
 	default:
 		// для запросов, которые не возвращают данные, параметры ответа не должны быть описаны
-		if len(q.Out.Fields) != 0 {
-			return NewError(nil, n, "unused parameters for data output are set")
+		if len(q.Out.Fields) != 0 || q.Out.Auto {
+			errs.Add(NewError(CodeOutParams, nil, outNode,
+				"unused parameters for data output are set for query type %v", q.Type))
 		}
 	}
 
-	return nil
+	return errs.Err()
+}
+
+// pointerDepth возвращает количество вложенных указателей в описании типа с учётом скобок
+// и пробелов ("* *T" и "(**T)" — два указателя). Для некорректных типов возвращает 0:
+// ошибка в описании типа возвращается при разборе поля.
+func pointerDepth(typ string) int {
+	expr, err := ParseType(typ)
+	if err != nil {
+		return 0
+	}
+
+	var depth int
+	for {
+		switch t := expr.(type) {
+		case *ast.ParenExpr:
+			expr = t.X
+		case *ast.StarExpr:
+			depth++
+			expr = t.X
+		default:
+			return depth
+		}
+	}
 }
@@ -2,19 +2,99 @@ package config
 
 import (
 	"fmt"
+	"strings"
+	"unicode/utf8"
 
 	"gopkg.in/yaml.v3"
 )
 
+// source описывает исходный файл с описанием запросов.
+type source struct {
+	name  string   // имя файла
+	lines []string // строки файла
+}
+
+// newSource возвращает описание исходного файла с указанным именем и содержимым.
+func newSource(name string, data []byte) *source {
+	return &source{
+		name:  name,
+		lines: strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n"),
+	}
+}
+
+// line возвращает строку файла с указанным номером, начиная с 1.
+func (s *source) line(n int) string {
+	if s == nil || n < 1 || n > len(s.lines) {
+		return ""
+	}
+
+	return s.lines[n-1]
+}
+
 // position описывает ссылку на строку и позицию в исходном описании YAML.
-type position [2]int
+type position struct {
+	line   int     // номер строки
+	column int     // номер колонки
+	length int     // длина значения в символах
+	src    *source // исходный файл
+}
 
 // Source возвращает строковое представление номера строки и позиции в исходном файле.
 func (s position) Source() string {
-	return fmt.Sprintf("%d:%d", s[0], s[1])
+	return fmt.Sprintf("%d:%d", s.line, s.column)
 }
 
-// parseSource.
+// error формирует описание ошибки с текущей позицией.
+func (s position) error(code Code, err error, format string, args ...any) error {
+	qerr := Error{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+		Line:    s.line,
+		Column:  s.column,
+		Length:  s.length,
+		err:     err,
+	}
+
+	if s.src != nil {
+		qerr.File = s.src.name
+		qerr.Snippet = s.src.line(s.line)
+	}
+
+	return qerr
+}
+
+// parseSource возвращает позицию YAML-ноды в исходном файле.
 func parseSource(n *yaml.Node) position {
-	return position{n.Line, n.Column}
+	if n == nil {
+		return position{}
+	}
+
+	pos := position{line: n.Line, column: n.Column, length: 1}
+	// для однострочных скалярных значений выделяем всё значение целиком
+	if n.Kind == yaml.ScalarNode && n.Value != "" && !strings.ContainsAny(n.Value, "\r\n") {
+		pos.length = utf8.RuneCountInString(n.Value)
+		if n.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+			pos.length += 2 // кавычки
+		}
+	}
+
+	return pos
+}
+
+// kindName возвращает название типа YAML-ноды.
+func kindName(k yaml.Kind) string {
+	switch k {
+	case yaml.DocumentNode:
+		return "document"
+	case yaml.SequenceNode:
+		return "sequence"
+	case yaml.MappingNode:
+		return "mapping"
+	case yaml.ScalarNode:
+		return "scalar"
+	case yaml.AliasNode:
+		return "alias"
+	default:
+		return fmt.Sprint(k)
+	}
 }
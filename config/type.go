@@ -71,7 +71,7 @@ func (qt *Type) UnmarshalYAML(n *yaml.Node) error {
 	var err error
 	*qt, err = parseType(n.Value)
 	if err != nil {
-		return NewError(err, n, err.Error()) // оборачиваем ошибку
+		return NewError(CodeQueryType, nil, n, "%v", err)
 	}
 
 	return nil
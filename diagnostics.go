@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+
+	"github.com/mdigger/sqlgen/config"
+	"github.com/mdigger/sqlgen/generator"
+	"github.com/urfave/cli/v3"
+)
+
+// Поддерживаемые форматы вывода диагностики.
+const (
+	diagnosticsText  = "text"
+	diagnosticsJSON  = "json"
+	diagnosticsSARIF = "sarif"
+)
+
+// diagnosticsFlag описывает флаг для выбора формата вывода диагностики.
+var diagnosticsFlag = &cli.StringFlag{
+	Name:  "diagnostics",
+	Usage: "output problems in `format`: text, json or sarif",
+	Value: diagnosticsText,
+	Action: func(_ *cli.Context, format string) error {
+		switch format {
+		case diagnosticsText, diagnosticsJSON, diagnosticsSARIF:
+			return nil
+		default:
+			return fmt.Errorf("unsupported diagnostics format %q", format)
+		}
+	},
+}
+
+// errProblems возвращается командой, если найдены проблемы, которые уже добавлены
+// в список для вывода.
+var errProblems = errors.New("problems found")
+
+// actionFunc описывает обработчик команды, который добавляет найденные проблемы в список.
+type actionFunc func(c *cli.Context, problems *config.Errors) error
+
+// withDiagnostics возвращает обработчик команды, который выводит найденные проблемы
+// в формате, заданном флагом diagnostics.
+func withDiagnostics(action actionFunc) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		var problems config.Errors
+		err := action(c, &problems)
+		if err != nil && !errors.Is(err, errProblems) {
+			problems.Add(err)
+		}
+
+		if err := printProblems(c, problems); err != nil {
+			return err
+		}
+
+		if err != nil {
+			return cli.Exit("", 1) // проблемы уже выведены
+		}
+
+		return nil
+	}
+}
+
+// printProblems выводит найденные проблемы в формате, заданном флагом diagnostics.
+func printProblems(c *cli.Context, problems config.Errors) error {
+	switch format := c.String(diagnosticsFlag.Name); format {
+	case "", diagnosticsText:
+		for _, problem := range problems {
+			log.Println(problem)
+		}
+
+		return nil
+
+	default:
+		return writeDiagnostics(c.App.Writer, format, diagnostics(problems))
+	}
+}
+
+// diagnostic описывает найденную проблему.
+type diagnostic struct {
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Length   int    `json:"length,omitempty"`
+	Severity string `json:"severity"`
+	Code     string `json:"code,omitempty"`
+	Message  string `json:"message"`
+	Query    string `json:"query,omitempty"`
+}
+
+// diagnostics возвращает описание списка проблем для вывода.
+func diagnostics(errs config.Errors) []diagnostic {
+	list := make([]diagnostic, len(errs))
+	for i, e := range errs {
+		list[i] = diagnostic{
+			File:     filepath.ToSlash(e.File),
+			Line:     e.Line,
+			Column:   e.Column,
+			Length:   e.Length,
+			Severity: e.Severity.String(),
+			Code:     string(e.Code),
+			Message:  e.Text(),
+			Query:    e.Query,
+		}
+	}
+
+	return list
+}
+
+// writeDiagnostics выводит список проблем в указанном формате.
+func writeDiagnostics(w io.Writer, format string, list []diagnostic) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	switch format {
+	case diagnosticsJSON:
+		return enc.Encode(list)
+	case diagnosticsSARIF:
+		return enc.Encode(sarifLog(list))
+	default:
+		return errors.New("unsupported diagnostics format")
+	}
+}
+
+// sarifLog возвращает описание проблем в формате SARIF 2.1.0.
+func sarifLog(list []diagnostic) any {
+	type (
+		text struct {
+			Text string `json:"text"`
+		}
+		rule struct {
+			ID               string `json:"id"`
+			ShortDescription text   `json:"shortDescription"`
+		}
+		region struct {
+			StartLine   int `json:"startLine,omitempty"`
+			StartColumn int `json:"startColumn,omitempty"`
+			EndColumn   int `json:"endColumn,omitempty"`
+		}
+		physicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+			Region *region `json:"region,omitempty"`
+		}
+		location struct {
+			PhysicalLocation physicalLocation `json:"physicalLocation"`
+		}
+		result struct {
+			RuleID    string     `json:"ruleId,omitempty"`
+			Level     string     `json:"level"`
+			Message   text       `json:"message"`
+			Locations []location `json:"locations,omitempty"`
+		}
+	)
+
+	rules := make([]rule, 0, len(config.Codes()))
+	for _, code := range config.Codes() {
+		rules = append(rules, rule{ID: string(code), ShortDescription: text{code.Description()}})
+	}
+
+	results := make([]result, 0, len(list))
+	for _, d := range list {
+		r := result{
+			RuleID:  d.Code,
+			Level:   d.Severity,
+			Message: text{d.Message},
+		}
+
+		if d.File != "" {
+			var loc location
+			loc.PhysicalLocation.ArtifactLocation.URI = d.File
+			if d.Line > 0 {
+				loc.PhysicalLocation.Region = &region{StartLine: d.Line, StartColumn: d.Column}
+				if d.Column > 0 && d.Length > 0 {
+					loc.PhysicalLocation.Region.EndColumn = d.Column + d.Length
+				}
+			}
+
+			r.Locations = []location{loc}
+		}
+
+		results = append(results, r)
+	}
+
+	return map[string]any{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []any{map[string]any{
+			"tool": map[string]any{
+				"driver": map[string]any{
+					"name":           "sqlgen",
+					"version":        generator.Version,
+					"informationUri": "https://" + generator.Module,
+					"rules":          rules,
+				},
+			},
+			"results": results,
+		}},
+	}
+}
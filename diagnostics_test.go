@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mdigger/sqlgen/generator"
+	"github.com/urfave/cli/v3"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// testdata содержит путь к каталогу с эталонными файлами, который не зависит от смены
+// текущего каталога в тестах.
+var testdata, _ = filepath.Abs("testdata")
+
+// golden сравнивает данные с содержимым файла testdata/name.golden. С флагом -update
+// файл перезаписывается.
+func golden(t *testing.T, name string, data []byte) {
+	t.Helper()
+
+	filename := filepath.Join(testdata, name+".golden")
+	if *update {
+		if err := os.WriteFile(filename, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		return
+	}
+
+	want, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data, want) {
+		t.Errorf("%s mismatch:\n--- got\n%s\n--- want\n%s", filename, data, want)
+	}
+}
+
+// diagnosticsQueries содержит описания запросов с проблемами в многострочных значениях
+// и в строках с табуляциями.
+const diagnosticsQueries = `# Return the user.
+get user:
+  type: one
+  sql: |
+    select id
+    from users
+    where id = ?
+  out:
+    id: int64
+
+# Return all users.
+list users:
+  type: many
+  sql: select * from users
+  out:
+    id:	int64
+    name:	'[]rune'
+
+# Remove the user.
+remove user:
+  type: exec
+  sql:	delete from users where id = ?
+`
+
+// checkOutput выполняет команду check с указанным форматом диагностики в каталоге с файлом
+// queries.yaml и возвращает вывод проблем: сообщения лога для текстового формата и
+// вывод команды для остальных.
+func checkOutput(t *testing.T, format string) []byte {
+	t.Helper()
+
+	var logs, out bytes.Buffer
+	log.SetOutput(&logs)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	})
+
+	app := &cli.App{
+		Name:           "sqlgen",
+		Writer:         &out,
+		ExitErrHandler: func(*cli.Context, error) {}, // не завершаем процесс тестов
+		Commands: []*cli.Command{{
+			Name:   "check",
+			Flags:  generateFlags,
+			Action: withDiagnostics(checkCmd),
+		}},
+	}
+
+	args := []string{"sqlgen", "check", "--name", "db", "--diagnostics", format, "queries.yaml"}
+	if err := app.Run(args); err == nil {
+		t.Fatalf("%s: check succeeded", format)
+	}
+
+	if format == diagnosticsText {
+		return logs.Bytes()
+	}
+
+	return out.Bytes()
+}
+
+func TestDiagnostics(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("queries.yaml", []byte(diagnosticsQueries), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// версия генератора в отчёте SARIF не должна менять эталонный файл
+	version := []byte(`"version": "` + generator.Version + `"`)
+	for _, format := range []string{diagnosticsText, diagnosticsJSON, diagnosticsSARIF} {
+		data := bytes.ReplaceAll(checkOutput(t, format), version, []byte(`"version": "$VERSION"`))
+		golden(t, "diagnostics."+format, data)
+	}
+}
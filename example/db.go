@@ -40,12 +40,14 @@ func (q Queries) WithTx(ctx context.Context, opt *sql.TxOptions, f func(q Querie
 	}
 
 	defer func() {
-		if err == nil {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			_ = tx.Rollback()
+		} else {
 			err = tx.Commit()
 		}
-		if err != nil {
-			err = tx.Rollback()
-		}
 	}()
 
 	return f(Queries{db: tx})
@@ -1,6 +1,6 @@
 // Code generated by sqlgen. DO NOT EDIT.
 // version: github.com/mdigger/sqlgen v0.1.0
-// source: example/users.yaml
+// source: users.yaml
 
 package example
 
@@ -12,15 +12,16 @@ import (
 // *** select user ***
 
 type User struct {
-	ID      string
-	Name    string
-	Age     uint
-	Comment sql.NullString
+	ID      string         // user id
+	Name    string         // user name
+	Age     uint           // age
+	Comment sql.NullString // optional comment
 }
 
+// Return user information.
 func (q Queries) SelectUser(ctx context.Context, id string) (User, error) {
 	row := q.db.QueryRowContext(ctx, `-- select user
-select *
+select id, name, age, comment
 from `+"`"+`users`+"`"+`
 where id = ?`, id)
 
@@ -37,9 +38,10 @@ where id = ?`, id)
 
 // *** select all users ***
 
+// Return all users.
 func (q Queries) SelectAllUsers(ctx context.Context, f func(out User) error) error {
 	rows, err := q.db.QueryContext(ctx, `-- select all users
-select *
+select id, name, age, comment
 from users`)
 	if err != nil {
 		return err
@@ -71,6 +73,7 @@ from users`)
 
 // *** add new user ***
 
+// Add a new user.
 func (q Queries) AddNewUser(ctx context.Context, args User) error {
 	_, err := q.db.ExecContext(ctx, `-- add new user
 insert into users
@@ -93,6 +96,7 @@ type UpdateUserParams struct {
 	ID      string
 }
 
+// Update user information.
 func (q Queries) UpdateUser(ctx context.Context, args UpdateUserParams) error {
 	result, err := q.db.ExecContext(ctx, `-- update user
 update users
@@ -117,3 +121,39 @@ where id = ?`,
 
 	return nil
 }
+
+// *** delete old users ***
+
+// Delete users older than the given age.
+func (q Queries) DeleteOldUsers(ctx context.Context, age uint) (int64, error) {
+	result, err := q.db.ExecContext(ctx, `-- delete old users
+delete from users
+where age > ?`, age)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// *** add user event ***
+
+type AddUserEventParams struct {
+	UserID string
+	Event  string
+}
+
+// Add a user event and return its identifier generated by the server.
+func (q Queries) AddUserEvent(ctx context.Context, args AddUserEventParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, `-- add user event
+insert into user_events
+  (user_id, event)
+values (?, ?)`,
+		args.UserID,
+		args.Event)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
@@ -0,0 +1,302 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"path"
+
+	"github.com/mdigger/sqlgen/config"
+)
+
+// typeChecker проверяет корректность описания типов параметров запросов.
+type typeChecker struct {
+	imports map[string]string         // пакеты по префиксам
+	pkgs    map[string]*types.Package // загруженные пакеты по пути импорта или nil
+	scanner *types.Interface          // интерфейс sql.Scanner
+	time    types.Type                // тип time.Time
+	pkg     *types.Package            // пакет сгенерированной библиотеки
+	local   map[string]types.Type     // именованные типы библиотеки по названию
+}
+
+// newTypeChecker загружает описания пакетов, которые используются в типах list и в именованных
+// типах из настроек проекта [Generator.NamedTypes], и возвращает проверку типов. Если загрузка
+// пакетов [Generator.Packages] не задана, то типы из пакетов не проверяются.
+func (g Generator) newTypeChecker(list []string) (typeChecker, error) {
+	tc := typeChecker{
+		imports: g.imports,
+		pkg:     types.NewPackage(g.Package, g.Package),
+		local:   make(map[string]types.Type, len(g.NamedTypes)),
+	}
+
+	if g.Packages == nil {
+		return tc, nil
+	}
+
+	// формируем список пакетов, типы которых используются
+	paths := []string{"database/sql", "time"}
+	for _, t := range g.NamedTypes {
+		list = append(list, t.Type)
+	}
+
+	for _, typ := range list {
+		for _, prefix := range typePrefixes(typ) {
+			if path, ok := g.imports[prefix]; ok {
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	pkgs, err := g.Packages(paths...)
+	if err != nil {
+		return typeChecker{}, err
+	}
+
+	for _, path := range paths {
+		if pkgs[path] == nil {
+			return typeChecker{}, fmt.Errorf("package %q not loaded", path)
+		}
+	}
+
+	tc.pkgs = pkgs
+	tc.scanner = pkgs["database/sql"].Scope().Lookup("Scanner").Type().Underlying().(*types.Interface)
+	tc.time = pkgs["time"].Scope().Lookup("Time").Type()
+
+	return tc, nil
+}
+
+// checkNamedTypes проверяет описание именованных типов из настроек проекта.
+func (g Generator) checkNamedTypes() error {
+	if len(g.NamedTypes) == 0 {
+		return nil
+	}
+
+	tc, err := g.newTypeChecker(nil)
+	if err != nil {
+		return err
+	}
+
+	var errs config.Errors
+	declared := make(map[string]string) // название -> описание
+	for _, t := range g.NamedTypes {
+		errs = append(errs, g.checkTypeNames(t, nil, declared)...)
+	}
+
+	tc.declare(g.NamedTypes, &errs)
+
+	return errs.Err()
+}
+
+// checkTypes проверяет, что все типы данных, используемые в параметрах запросов, существуют,
+// а исходящие параметры могут быть прочитаны из ответа базы данных. Кроме стандартных типов
+// и типов из пакетов, в описании полей можно использовать именованные типы из настроек
+// проекта [Generator.NamedTypes] и из описания запросов local.
+// Возвращает все найденные ошибки.
+func (g Generator) checkTypes(qs []config.Query, local config.NamedTypes) error {
+	var list []string
+	for _, t := range local {
+		list = append(list, t.Type)
+	}
+
+	for _, q := range qs {
+		for _, fields := range [...][]config.Field{q.In.Fields, q.Out.Fields} {
+			for _, f := range fields {
+				list = append(list, f.Type)
+			}
+		}
+	}
+
+	tc, err := g.newTypeChecker(list)
+	if err != nil {
+		return err
+	}
+
+	// ошибки в описании типов из настроек проекта возвращаются при генерации основного
+	// файла библиотеки (см. [Generator.DB]), а не для каждого файла с описанием запросов
+	var errs config.Errors
+	tc.declare(g.NamedTypes, new(config.Errors))
+	tc.declare(local, &errs)
+
+	for _, q := range qs {
+		for _, f := range q.In.Fields {
+			if _, err := tc.resolve(f.Type); err != nil {
+				errs.Add(q.FieldErrorf(f, config.CodeFieldType, err, "field %q type", f.Name))
+			}
+		}
+
+		for _, f := range q.Out.Fields {
+			t, err := tc.resolve(f.Type)
+			if err != nil {
+				errs.Add(q.FieldErrorf(f, config.CodeFieldType, err, "field %q type", f.Name))
+			} else if !tc.scannable(t) {
+				errs.Add(q.FieldErrorf(f, config.CodeScan, nil,
+					"field %q type %s does not implement sql.Scanner and is not supported by driver",
+					f.Name, f.Type))
+			}
+		}
+	}
+
+	return errs.Err()
+}
+
+// declare добавляет именованные типы в список типов библиотеки. Базовый тип может
+// ссылаться только на типы, объявленные ранее. Ошибки в описании типов добавляются в errs.
+func (tc typeChecker) declare(ts config.NamedTypes, errs *config.Errors) {
+	for _, t := range ts {
+		if _, ok := tc.local[t.Name]; ok {
+			errs.Add(t.Errorf(config.CodeRedefined, nil, "type %q already declared", t.Name))
+			continue
+		}
+
+		underlying, err := tc.resolve(t.Type)
+		if err != nil {
+			errs.Add(t.Errorf(config.CodeFieldType, err, "type %q underlying type", t.Name))
+			continue
+		}
+
+		obj := types.NewTypeName(token.NoPos, tc.pkg, t.Name, nil)
+		tc.local[t.Name] = types.NewNamed(obj, underlying.Underlying(), nil)
+	}
+}
+
+// resolve возвращает описание типа данных по его строковому представлению.
+func (tc typeChecker) resolve(s string) (types.Type, error) {
+	expr, err := config.ParseType(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return tc.resolveExpr(expr)
+}
+
+// resolveExpr возвращает описание типа данных по его синтаксическому дереву.
+func (tc typeChecker) resolveExpr(expr ast.Expr) (types.Type, error) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if typ, ok := tc.local[t.Name]; ok {
+			return typ, nil
+		}
+
+		obj, ok := types.Universe.Lookup(t.Name).(*types.TypeName)
+		if !ok {
+			return nil, fmt.Errorf("unknown type %q", t.Name)
+		}
+
+		return obj.Type(), nil
+
+	case *ast.SelectorExpr:
+		prefix := t.X.(*ast.Ident).Name
+		lib, ok := tc.imports[prefix]
+		if !ok {
+			return nil, fmt.Errorf("unknown package prefix %q", prefix)
+		}
+
+		// без загруженных пакетов тип не проверяется и считается допустимым
+		pkg := tc.pkgs[lib]
+		if pkg == nil {
+			return types.Typ[types.Invalid], nil
+		}
+
+		// синоним в импорте не пишется, если префикс совпадает с последним элементом пути,
+		// поэтому он должен совпадать и с настоящим названием пакета
+		if name := pkg.Name(); name != prefix && prefix == path.Base(lib) {
+			return nil, fmt.Errorf("package %q is named %q: specify the prefix explicitly (%s:%s)",
+				lib, name, name, lib)
+		}
+
+		obj := pkg.Scope().Lookup(t.Sel.Name)
+		if obj == nil || !obj.Exported() {
+			return nil, fmt.Errorf("type %s.%s not defined in package %q", prefix, t.Sel.Name, lib)
+		}
+
+		if _, ok := obj.(*types.TypeName); !ok {
+			return nil, fmt.Errorf("%s.%s is not a type", prefix, t.Sel.Name)
+		}
+
+		return obj.Type(), nil
+
+	case *ast.StarExpr:
+		elem, err := tc.resolveExpr(t.X)
+		if err != nil {
+			return nil, err
+		}
+
+		return types.NewPointer(elem), nil
+
+	case *ast.ArrayType:
+		elem, err := tc.resolveExpr(t.Elt)
+		if err != nil {
+			return nil, err
+		}
+
+		if t.Len == nil {
+			return types.NewSlice(elem), nil
+		}
+
+		length, ok := constant.Int64Val(constant.MakeFromLiteral(t.Len.(*ast.BasicLit).Value, token.INT, 0))
+		if !ok {
+			return nil, errors.New("invalid array length")
+		}
+
+		return types.NewArray(elem, length), nil
+
+	case *ast.MapType:
+		key, err := tc.resolveExpr(t.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := tc.resolveExpr(t.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		return types.NewMap(key, value), nil
+
+	case *ast.InterfaceType:
+		return types.NewInterfaceType(nil, nil).Complete(), nil
+
+	case *ast.ParenExpr:
+		return tc.resolveExpr(t.X)
+
+	default:
+		return nil, errors.New("unsupported type expression")
+	}
+}
+
+// scannable возвращает true, если значение указанного типа может быть прочитано из ответа
+// базы данных: тип поддерживает интерфейс sql.Scanner или поддерживается драйвером напрямую.
+// Типы из пакетов, описания которых не загружались, считаются допустимыми.
+func (tc typeChecker) scannable(t types.Type) bool {
+	// указатель позволяет получать значение NULL
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+
+	if tc.pkgs == nil {
+		if t.Underlying() == types.Typ[types.Invalid] {
+			return true
+		}
+	} else if types.Implements(types.NewPointer(t), tc.scanner) || types.Identical(t, tc.time) {
+		return true
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return u.Info()&(types.IsBoolean|types.IsInteger|types.IsFloat|types.IsString) != 0 &&
+			u.Kind() != types.UnsafePointer
+
+	case *types.Slice:
+		elem, ok := u.Elem().Underlying().(*types.Basic)
+		return ok && elem.Kind() == types.Byte
+
+	case *types.Interface:
+		return u.Empty()
+
+	default:
+		return false
+	}
+}
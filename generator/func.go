@@ -5,18 +5,76 @@ import (
 	"strings"
 	"text/template"
 	"unicode"
+
+	"github.com/mdigger/sqlgen/config"
 )
 
-// funcMap регистрирует функции для использования в шаблонах.
-var funcMap = template.FuncMap{
-	"name":   publicName,     // конвертирует строку в название экспортируемого типа
-	"param":  param,          // проверяет название параметра
-	"escape": escapeBacktick, // экранирует символ "`"
+// commonInitialisms содержит список распространённых аббревиатур, которые в названиях golang
+// принято записывать заглавными буквами.
+var commonInitialisms = []string{
+	"ACL", "API", "ASCII", "CPU", "CSS", "DNS", "EOF", "GUID", "HTML", "HTTP", "HTTPS",
+	"ID", "IP", "JSON", "LHS", "QPS", "RAM", "RHS", "RPC", "SLA", "SMTP", "SQL", "SSH",
+	"TCP", "TLS", "TTL", "UDP", "UI", "UID", "UUID", "URI", "URL", "UTF8", "VM", "XML",
+	"XMPP", "XSRF", "XSS",
+}
+
+// namer формирует названия для сгенерированного кода с учётом списка аббревиатур.
+type namer struct {
+	initialisms map[string]bool // аббревиатуры в верхнем регистре
+}
+
+// newNamer возвращает новый генератор названий со стандартным списком аббревиатур
+// и дополнительными аббревиатурами.
+func newNamer(initialisms ...string) namer {
+	n := namer{initialisms: make(map[string]bool, len(commonInitialisms)+len(initialisms))}
+	for _, list := range [...][]string{commonInitialisms, initialisms} {
+		for _, s := range list {
+			if s = strings.TrimSpace(s); s != "" {
+				n.initialisms[strings.ToUpper(s)] = true
+			}
+		}
+	}
+
+	return n
+}
+
+// funcMap возвращает функции для использования в шаблонах.
+func (n namer) funcMap() template.FuncMap {
+	return template.FuncMap{
+		"name":      n.publicName,   // конвертирует строку в название экспортируемого типа
+		"funcName":  n.funcName,     // возвращает название функции запроса
+		"fieldName": n.fieldName,    // возвращает название поля структуры
+		"param":     n.param,        // проверяет название параметра
+		"escape":    escapeBacktick, // экранирует символ "`"
+		"testValue": testValue,      // значение типа для сгенерированных тестов
+		"scanValue": scanValue,      // чтение и запись значения именованного типа
+		"enumName":  n.enumName,     // возвращает название константы значения перечисления
+	}
+}
+
+// funcName возвращает название функции для запроса.
+// Явно заданное в описании запроса название имеет приоритет.
+func (n namer) funcName(q config.Query) string {
+	if q.GoName != "" {
+		return q.GoName
+	}
+
+	return n.publicName(q.Name)
+}
+
+// fieldName возвращает название поля структуры.
+// Явно заданное в описании поля название имеет приоритет.
+func (n namer) fieldName(f config.Field) string {
+	if f.GoName != "" {
+		return f.GoName
+	}
+
+	return n.name(f.Name, true)
 }
 
-// name конвертирует название запроса в название функции golang.
-func publicName(s string) string {
-	s = name(s, true)
+// publicName конвертирует название запроса в название функции golang.
+func (n namer) publicName(s string) string {
+	s = n.name(s, true)
 
 	// подменяем некоторые используемые нами названия параметров
 	if s == "Queries" {
@@ -26,64 +84,118 @@ func publicName(s string) string {
 	return s
 }
 
+// enumName возвращает название константы для значения перечисления v: название типа
+// и название значения (Status и "in progress" -> StatusInProgress).
+func (n namer) enumName(t config.NamedType, v config.EnumValue) string {
+	return t.Name + n.name(v.Name, true)
+}
+
 // param возвращает название параметра.
-func param(s string) string {
+func (n namer) param(s string) string {
 	// подменяем некоторые используемые нами названия параметров
 	switch s {
 	case "ctx", "f", "q", "row", "rows", "err", "result", "out", "Queries":
 		return s + "_"
 	default:
-		return name(s, false)
+		return n.name(s, false)
 	}
 }
 
 // name приводит строку к формату названия в golang.
 // Параметр public влияет на заглавную первую букву в имени.
-func name(s string, public bool) string {
+//
+// Строка разбивается на слова по символам, не являющимся буквами или цифрами, и по переходу
+// от строчной буквы к заглавной. Слова, совпадающие с аббревиатурами, записываются заглавными
+// буквами (user_url -> UserURL), кроме первого слова непубличного названия (id_prefix -> idPrefix).
+func (n namer) name(s string, public bool) string {
 	// проверяем использование ключевых слов
 	if !public && token.IsKeyword(s) {
 		return "_" + s
 	}
 
+	var buf strings.Builder
+	for i, word := range splitWords(s) {
+		runes := []rune(word)
+		switch {
+		case i == 0 && !unicode.IsLetter(runes[0]) && runes[0] != '_':
+			// чтобы гарантированно начиналось с буквы, добавляем подчёркивание, если это не так
+			buf.WriteRune('_')
+			buf.WriteString(word)
+
+		case i == 0 && !public:
+			buf.WriteString(word) // первое слово непубличного названия оставляем как есть
+
+		case n.initialisms[strings.ToUpper(word)]:
+			buf.WriteString(strings.ToUpper(word))
+
+		default:
+			runes[0] = unicode.ToTitle(runes[0]) // приводим первую букву к верхнему регистру
+			buf.WriteString(string(runes))
+		}
+	}
+
+	return buf.String()
+}
+
+// splitWords разбивает строку на слова по символам, не являющимся буквами или цифрами,
+// и по переходу от строчной буквы или цифры к заглавной.
+func splitWords(s string) []string {
 	var (
-		buf            strings.Builder
-		first          = true // первый символ в названии
-		capitalizeNext = public
+		words []string
+		word  []rune
+		prev  rune
 	)
 
 	for _, r := range s {
 		switch {
-		case first:
-			first = false // больше первого символа при формировании не случится
-			// чтобы гарантированно начиналось с буквы, добавляем подчёркивание, если это не так
-			if !unicode.IsLetter(r) && r != '_' {
-				buf.WriteRune('_')
-			} else if public {
-				r = unicode.ToTitle(r) // приводим к верхнему регистру
-				capitalizeNext = false // следующую букву приводить не требуется
+		case !unicode.In(r, unicode.Letter, unicode.Number) && (r != '_' || len(words) > 0 || len(word) > 0):
+			// символ не является ни буквой, ни цифрой -- начинаем новое слово;
+			// подчёркивание в начале строки сохраняется
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = word[:0]
 			}
 
-		case !unicode.In(r, unicode.Letter, unicode.Number):
-			capitalizeNext = true // символ не является ни буквой, ни цифрой -- сделать следующий заглавной буквой
+			continue
 
-			continue // пропускаем текущий символ
-
-		case capitalizeNext:
-			r = unicode.ToTitle(r) // приводим символ к верхнему регистру
-			capitalizeNext = false // сбрасываем флаг, что следующий символ должен быть заглавным
+		case unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)) && len(word) > 0:
+			words = append(words, string(word))
+			word = word[:0]
 		}
 
-		buf.WriteRune(r) // сохраняем текущий символ
+		word = append(word, r)
+		prev = r
 	}
 
-	name := buf.String()
-	if strings.HasSuffix(name, "Id") {
-		name = name[:len(name)-2] + "ID" // скорее всего это идентификатор
+	if len(word) > 0 {
+		words = append(words, string(word))
 	}
 
-	return name
+	return words
 }
 
 func escapeBacktick(s string) string {
 	return strings.Replace(s, "`", "`+\"`\"+`", -1)
 }
+
+// valueScan описывает преобразование значения именованного типа в методах Scan и Value.
+type valueScan struct {
+	Type  string // тип значения driver.Value
+	Null  string // тип из пакета database/sql для чтения значения
+	Field string // поле со значением в типе для чтения
+}
+
+// scanValue возвращает описание преобразования значения именованного типа t для генерации
+// методов Scan и Value.
+func scanValue(t config.NamedType) valueScan {
+	switch typ := t.ValueType(); typ {
+	case "int64":
+		return valueScan{Type: typ, Null: "NullInt64", Field: "Int64"}
+	case "float64":
+		return valueScan{Type: typ, Null: "NullFloat64", Field: "Float64"}
+	case "bool":
+		return valueScan{Type: typ, Null: "NullBool", Field: "Bool"}
+	default:
+		return valueScan{Type: "string", Null: "NullString", Field: "String"}
+	}
+}
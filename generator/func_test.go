@@ -0,0 +1,91 @@
+package generator
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitWords(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"user", []string{"user"}},
+		{"user_url", []string{"user", "url"}},
+		{"get-user-by-id", []string{"get", "user", "by", "id"}},
+		{"list all  users", []string{"list", "all", "users"}},
+		{"userURL", []string{"user", "URL"}},
+		{"UserName", []string{"User", "Name"}},
+		{"HTTPServer", []string{"HTTPServer"}},
+		{"order2user", []string{"order2user"}},
+		{"user2ID", []string{"user2", "ID"}},
+		{"2fa_code", []string{"2fa", "code"}},
+		{"_private", []string{"_private"}},
+		{"__", []string{"_"}},
+		{"--", nil},
+		{"имя_поля", []string{"имя", "поля"}},
+	} {
+		if got := splitWords(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("splitWords(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestName(t *testing.T) {
+	n := newNamer("SKU")
+	for _, tt := range []struct {
+		in             string
+		public, params string
+	}{
+		{"", "", ""},
+		{"x", "X", "x"},
+		{"user_url", "UserURL", "userURL"},
+		{"id_prefix", "IDPrefix", "idPrefix"},
+		{"user id", "UserID", "userID"},
+		{"get-user-by-id", "GetUserByID", "getUserByID"},
+		{"userURL", "UserURL", "userURL"},
+		{"already_CAPS", "AlreadyCAPS", "alreadyCAPS"},
+		{"uuid", "UUID", "uuid"},
+		{"utf8_name", "UTF8Name", "utf8Name"},
+		{"order2user", "Order2user", "order2user"},
+		{"2fa_code", "_2faCode", "_2faCode"},
+		{"sku_list", "SKUList", "skuList"},
+		{"type", "Type", "_type"},
+		{"_private", "_private", "_private"},
+	} {
+		if got := n.name(tt.in, true); got != tt.public {
+			t.Errorf("name(%q, true) = %q, want %q", tt.in, got, tt.public)
+		}
+
+		if got := n.name(tt.in, false); got != tt.params {
+			t.Errorf("name(%q, false) = %q, want %q", tt.in, got, tt.params)
+		}
+	}
+}
+
+func TestInitialisms(t *testing.T) {
+	// стандартные аббревиатуры не зависят от дополнительных, а пустые значения пропускаются
+	n := newNamer(" sku ", "", "Qr")
+	for in, want := range map[string]string{
+		"item_sku":  "ItemSKU",
+		"qr_code":   "QRCode",
+		"json_data": "JSONData",
+		"user_ids":  "UserIds",
+		"http_url":  "HTTPURL",
+	} {
+		if got := n.name(in, true); got != want {
+			t.Errorf("name(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	if got := newNamer().name("item_sku", true); got != "ItemSku" {
+		t.Errorf("name without initialisms = %q, want %q", got, "ItemSku")
+	}
+
+	for _, s := range commonInitialisms {
+		if got := newNamer().name(s, true); got != s {
+			t.Errorf("initialism %q: name = %q", s, got)
+		}
+	}
+}
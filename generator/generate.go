@@ -2,46 +2,102 @@ package generator
 
 import (
 	"bytes"
+	"context"
 	_ "embed" // use embedded template
+	"errors"
 	"fmt"
+	"go/ast"
 	"go/format"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 
 	"github.com/mdigger/sqlgen/config"
+	"github.com/mdigger/sqlgen/schema"
 )
 
 var (
 	//go:embed generator.tmpl
 	queryTemplates string
-	// tmpl содержит разобранные шаблоны для генерации кода.
-	tmpl = template.Must(template.New("").Funcs(funcMap).Parse(queryTemplates))
+	//go:embed tests.tmpl
+	testTemplates string
+	// tmpl содержит разобранные шаблоны для генерации кода. После разбора шаблоны не
+	// изменяются, а только выполняются или клонируются, поэтому text/template допускает
+	// их параллельное выполнение через ExecuteTemplate.
+	tmpl = template.Must(template.Must(template.New("").Funcs(newNamer().funcMap()).
+		Parse(queryTemplates)).Parse(testTemplates))
 )
 
 const (
 	Module  = "github.com/mdigger/sqlgen"
 	Version = "v0.1.0"
+
+	// Header содержит первую строку каждого сгенерированного файла,
+	// по которой можно определить, что файл создан генератором.
+	Header = "// Code generated by sqlgen. DO NOT EDIT."
+
+	// DataVersion задаёт версию описания данных, передаваемых в шаблоны (QueryData и DBData).
+	// Версия увеличивается при любом несовместимом изменении этих данных, чтобы
+	// пользовательские шаблоны могли её проверить.
+	DataVersion = 3
 )
 
 // Generator описывает данные генератора.
+//
+// После создания и настройки генератора методы Query и DB можно вызывать параллельно:
+// они не изменяют данные генератора, шаблоны выполняются без изменения, а загрузка
+// описаний пакетов [GoPackages] для проверки типов защищена блокировкой.
 type Generator struct {
 	Name    string // название
 	Version string // версия
 	Package string // название пакета
+	// Dialect задаёт диалект SQL для проверки текстов запросов.
+	Dialect config.Dialect
+	// Tests включает генерацию тестов запросов методом [Generator.Generate].
+	Tests bool
+	// Schema задаёт структуру базы данных, по которой определяются исходящие параметры
+	// запросов, если они не описаны явно (см. [Generator.InferOut]).
+	Schema *schema.Schema
+	// Types задаёт соответствие типов колонок SQL типам golang для определения исходящих
+	// параметров. Если не задано, то используется [schema.DefaultTypes].
+	Types schema.TypeMap
+	// NamedTypes задаёт именованные типы, которые объявляются в основном файле библиотеки
+	// и могут использоваться в описании запросов из всех файлов по названию.
+	NamedTypes config.NamedTypes
+	// Packages задаёт загрузку описаний пакетов для проверки типов параметров запросов,
+	// заданных с префиксом пакета. Если не задана, то такие типы не проверяются, а генератор
+	// не обращается к файловой системе и не запускает других программ.
+	Packages PackageLoader
 
-	imports map[string]string // список поддерживаемых импортов пакетов по префиксам
+	imports map[string]string  // список поддерживаемых импортов пакетов по префиксам
+	namer   namer              // формирование названий
+	tmpl    *template.Template // шаблоны с функциями формирования названий
 }
 
 // New возвращает новый генератор с заданным именем библиотеки для генерации.
 // Опционально указываются дополнительный пакеты для импорта.
+//
+// Если имя не задано, то используется название текущего каталога. Типы из пакетов
+// проверяются по их описаниям, загруженным через [GoPackages]. Для использования
+// генератора в качестве библиотеки удобнее функция [NewWithOptions].
 func New(name string, packages ...string) Generator {
 	if name == "" || name == "." {
 		name, _ = os.Getwd() // используем текущий каталог
 	}
 
+	g := newGenerator(name, packages...)
+	g.Packages = GoPackages()
+
+	return g
+}
+
+// newGenerator возвращает новый генератор для библиотеки с заданным именем или путём
+// и списком дополнительных пакетов для импорта.
+func newGenerator(name string, packages ...string) Generator {
 	name = path.Base(filepath.ToSlash(name)) // отделяем имя от пути
 	switch name {
 	case "", ".", "/":
@@ -64,8 +120,7 @@ func New(name string, packages ...string) Generator {
 		if idx := strings.IndexByte(item, ':'); idx > 0 {
 			imports[item[:idx]] = item[idx+1:] // задан префикс
 		} else {
-			// TODO: добавить разбор версии пакета
-			imports[path.Base(item)] = item
+			imports[packageName(item)] = item // используем название пакета
 		}
 	}
 
@@ -74,52 +129,372 @@ func New(name string, packages ...string) Generator {
 		Version: Version,
 		Package: name,
 		imports: imports,
+		namer:   newNamer(),
+		tmpl:    tmpl,
 	}
 }
 
-// Query генерирует и возвращает код для работы с запросами.
-func (g Generator) Query(source string, queries []config.Query) ([]byte, error) {
-	// определяем список библиотек, используемых в запросах, для импорта
-	imports, err := g.getImports(queries)
+// SetInitialisms задаёт дополнительный список аббревиатур, которые в сгенерированных названиях
+// записываются заглавными буквами (например, "SKU" для sku_code -> SKUCode).
+// Стандартный список аббревиатур golang (ID, URL, HTTP, JSON и т.д.) используется всегда.
+// Метод нельзя вызывать одновременно с генерацией кода.
+func (g *Generator) SetInitialisms(initialisms ...string) {
+	g.namer = newNamer(initialisms...)
+	g.tmpl = template.Must(g.tmpl.Clone()).Funcs(g.namer.funcMap())
+}
+
+// SetTemplates заменяет шаблоны генерации кода шаблонами из файлов *.tmpl в корне fsys.
+// Файлы обрабатываются в алфавитном порядке. Блоки {{define "name"}} в файлах заменяют
+// стандартные шаблоны с тем же названием, а остальные шаблоны остаются стандартными.
+// Содержимое файла вне блоков define, если оно не пустое, задаёт шаблон с названием файла
+// без расширения.
+func (g *Generator) SetTemplates(fsys fs.FS) error {
+	names, err := fs.Glob(fsys, "*.tmpl")
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("templates: %w", err)
+	}
+
+	t := template.Must(g.tmpl.Clone())
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return fmt.Errorf("templates: %w", err)
+		}
+
+		if _, err := t.New(strings.TrimSuffix(name, ".tmpl")).Parse(string(data)); err != nil {
+			return fmt.Errorf("templates: %w", err)
+		}
+	}
+
+	g.tmpl = t
+
+	return nil
+}
+
+// Templates возвращает исходный текст стандартных шаблонов генерации кода.
+func Templates() string {
+	return queryTemplates + "\n" + testTemplates
+}
+
+// QueryData описывает данные, передаваемые в шаблон "generate queries" для генерации кода
+// запросов из одного файла. Версия описания задаётся константой DataVersion.
+type QueryData struct {
+	Generator                     // информация о генераторе
+	DataVersion int               // версия описания данных
+	Source      string            // название и путь исходного файла с данными
+	Imports     []Import          // список импортируемых библиотек
+	Types       config.NamedTypes // именованные типы из описания запросов
+	Queries     []config.Query    // список запросов
+}
+
+// DBData описывает данные, передаваемые в шаблон "generate db" для генерации кода
+// с основным описанием библиотеки. Версия описания задаётся константой DataVersion.
+type DBData struct {
+	Generator                     // информация о генераторе
+	DataVersion int               // версия описания данных
+	Source      string            // для основного модуля исходный файл не задаётся
+	Imports     []Import          // список импортируемых библиотек
+	Types       config.NamedTypes // именованные типы из настроек проекта
+}
+
+// Query генерирует и возвращает код для работы с запросами. Именованные типы из описания
+// запросов types ([config.Queries.Types]) объявляются в том же файле.
+func (g Generator) Query(source string, queries []config.Query, types ...config.NamedType) ([]byte, error) {
+	// определяем список библиотек, используемых в запросах, для импорта
+	var errs config.Errors
+	var extra []Import
+	if len(queries) > 0 {
+		extra = append(extra, Import{Path: "context"}) // используется в методах запросов
+	}
+
+	imports, err := g.getImports(queries, types, extra...)
+	errs.Add(err)
+
+	// проверяем, что названия функций, полей и типов не пересекаются
+	errs.Add(g.checkNames(queries, types))
+
+	// проверяем, что определены исходящие параметры запросов, которые не описаны явно
+	for _, q := range queries {
+		if q.Out.Auto && len(q.Out.Fields) == 0 {
+			errs.Add(q.OutErrorf(config.CodeOutParams, nil,
+				"parameters for outgoing data are not described for query type %v "+
+					"and the database schema to infer them is not set", q.Type))
+		}
+	}
+
+	// проверяем корректность описания типов данных параметров
+	if len(errs) == 0 {
+		errs.Add(g.checkTypes(queries, types))
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
 	}
 
 	// формируем данные для использования в шаблоне
-	data := struct {
-		Generator                   // информация о генераторе
-		Source    string            // название и путь исходного файла с данными
-		Imports   map[string]string // список импортируемых библиотек
-		Queries   []config.Query    // список запросов
-	}{
-		Generator: g,
-		Source:    source,
-		Imports:   imports,
-		Queries:   queries,
+	data := QueryData{
+		Generator:   g,
+		DataVersion: DataVersion,
+		Source:      source,
+		Imports:     imports,
+		Types:       types,
+		Queries:     queries,
 	}
 
 	// генерируем и возвращаем код для обработки запроса
-	return generate("generate queries", data)
+	return g.generate("generate queries", data)
+}
+
+// InferOut определяет по структуре базы данных [Generator.Schema] исходящие параметры
+// запросов, которые не описаны явно. Если структура базы данных не задана, то ничего
+// не делает: для таких запросов [Generator.Query] вернёт ошибку.
+func (g Generator) InferOut(qs *config.Queries) error {
+	if g.Schema == nil {
+		return nil
+	}
+
+	return g.Schema.Infer(qs, g.Types).Err()
+}
+
+// QueryTest генерирует и возвращает код тестов для запросов: для каждого запроса создаётся
+// тест, который выполняет сгенерированный метод с тестовой базой данных из [Generator.DBTest]
+// и проверяет текст запроса, порядок параметров и чтение результата. Именованные типы types
+// должны совпадать с переданными в [Generator.Query].
+func (g Generator) QueryTest(source string, queries []config.Query, types ...config.NamedType) ([]byte, error) {
+	imports, err := g.getImports(queries, nil) // объявления типов в тестах не используются
+	if err != nil {
+		return nil, err
+	}
+
+	// значения именованных типов в тестах формируются по их базовым типам
+	values := testValues{namer: g.namer, types: make(map[string]config.NamedType, len(g.NamedTypes)+len(types))}
+	for _, ts := range [...]config.NamedTypes{g.NamedTypes, types} {
+		for _, t := range ts {
+			values.types[t.Name] = t
+		}
+	}
+
+	g.tmpl = template.Must(g.tmpl.Clone()).Funcs(template.FuncMap{"testValue": values.value})
+
+	data := QueryData{
+		Generator:   g,
+		DataVersion: DataVersion,
+		Source:      source,
+		Imports:     imports,
+		Types:       types,
+		Queries:     queries,
+	}
+
+	return g.generate("generate queries test", data)
+}
+
+// DBTest возвращает сгенерированный код тестовой базы данных, которая используется
+// в тестах запросов. Тестовая база данных не требует внешних зависимостей.
+func (g Generator) DBTest() ([]byte, error) {
+	return g.generate("generate db test", DBData{Generator: g, DataVersion: DataVersion})
 }
 
-// DB возвращает сгенерированный код с описанием библиотеки запросов.
+// DB возвращает сгенерированный код с описанием библиотеки запросов и именованными типами
+// из настроек проекта [Generator.NamedTypes].
 func (g Generator) DB() ([]byte, error) {
+	// проверяем описание именованных типов и определяем библиотеки для импорта
+	if err := g.checkNamedTypes(); err != nil {
+		return nil, err
+	}
+
+	imports, err := g.getImports(nil, g.NamedTypes, dbImports...)
+	if err != nil {
+		return nil, err
+	}
+
 	// формируем данные для использования в шаблоне
-	data := struct {
-		Generator        // информация о генераторе
-		Source    string // для основного модуля исходный файл не задаётся
-	}{Generator: g}
+	data := DBData{
+		Generator:   g,
+		DataVersion: DataVersion,
+		Imports:     imports,
+		Types:       g.NamedTypes,
+	}
 
 	// генерируем и возвращаем код с основным описанием библиотеки
-	return generate("generate db", data)
+	return g.generate("generate db", data)
+}
+
+// QueryFiles генерирует код запросов из описания qs и, если включена генерация тестов
+// [Generator.Tests], код их тестов; для описания без запросов тесты не генерируются и
+// возвращается nil. Имя файла name используется в описании ошибок без позиции, а путь
+// source — в строке "// source:" сгенерированного кода.
+func (g Generator) QueryFiles(name, source string, qs *config.Queries) (code, test []byte, err error) {
+	code, err = g.Query(source, qs.Queries, qs.Types...)
+	if err != nil {
+		return nil, nil, generateError(name, err)
+	}
+
+	if !g.Tests || len(qs.Queries) == 0 {
+		return code, nil, nil
+	}
+
+	test, err = g.QueryTest(source, qs.Queries, qs.Types...)
+	if err != nil {
+		return nil, nil, generateError(name, fmt.Errorf("generate tests: %w", err))
+	}
+
+	return code, test, nil
+}
+
+// DBFiles генерирует основной файл библиотеки [DBFile] и, если включена генерация тестов
+// [Generator.Tests], файл с тестовой базой данных [DBTestFile]. Возвращает сгенерированные
+// файлы по их именам и все найденные ошибки.
+func (g Generator) DBFiles() (map[string][]byte, error) {
+	var errs config.Errors
+	files := make(map[string][]byte, 2)
+
+	data, err := g.DB()
+	if err != nil {
+		errs.Add(generateError("", fmt.Errorf("generate main: %w", err)))
+	}
+
+	files[DBFile] = data
+
+	if g.Tests {
+		data, err := g.DBTest()
+		if err != nil {
+			errs.Add(generateError("", fmt.Errorf("generate main tests: %w", err)))
+		}
+
+		files[DBTestFile] = data
+	}
+
+	return files, errs.Err()
+}
+
+// Generate генерирует код библиотеки по описаниям запросов inputs, заданным в виде имени
+// файла и его содержимого, и возвращает сгенерированные файлы по их именам: для каждого
+// описания запросов файл с именем [FileName] и основной файл библиотеки [DBFile].
+//
+// Функция не читает описания запросов из файлов и не записывает сгенерированный код: имена
+// файлов используются только для описания ошибок, формирования имён сгенерированных файлов
+// и строки "// source:" в них, поэтому их лучше задавать относительно каталога для записи
+// сгенерированных файлов. Описания используемых пакетов для проверки типов загружаются
+// только через [Generator.Packages], если она задана.
+//
+// В случае ошибок возвращается список всех найденных ошибок [config.Errors].
+// Предупреждения проверки SQL запросов ([config.Queries.Lint]) не считаются ошибками
+// и не возвращаются.
+func (g Generator) Generate(ctx context.Context, inputs map[string][]byte) (map[string][]byte, error) {
+	// обрабатываем описания в стабильном порядке, чтобы результат не зависел от запуска
+	names := make([]string, 0, len(inputs))
+	for name := range inputs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var errs config.Errors
+	files := make(map[string][]byte, len(inputs)+1)
+	sources := make(map[string]string, len(inputs)) // сгенерированный файл -> описание
+	var declared Declarations                       // названия, объявленные в описаниях
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		filename := FileName(name)
+		if other, ok := sources[filename]; ok {
+			errs.Add(config.Error{Code: config.CodeGenerate, File: name,
+				Message: fmt.Sprintf("generated file name %s conflicts with %q", filename, other)})
+			continue
+		}
+
+		sources[filename] = name
+
+		qs, err := config.ParseBytes(name, inputs[name])
+		if err != nil {
+			errs.Add(err)
+			continue
+		}
+
+		// определяем не описанные явно исходящие параметры по структуре базы данных
+		if err := g.InferOut(qs); err != nil {
+			errs.Add(err)
+			continue
+		}
+
+		// проверяем тексты SQL запросов, пропуская предупреждения
+		for _, problem := range qs.LintDialect(g.Dialect) {
+			if problem.Severity == config.SeverityError {
+				errs.Add(problem)
+			}
+		}
+
+		// проверяем, что названия функций и типов не пересекаются с названиями из других описаний
+		errs.Add(g.Declare(&declared, name, qs))
+
+		code, test, err := g.QueryFiles(name, filepath.ToSlash(name), qs)
+		if err != nil {
+			errs.Add(err)
+			continue
+		}
+
+		files[filename] = code
+		if test != nil {
+			files[TestFileName(name)] = test
+		}
+	}
+
+	db, err := g.DBFiles()
+	errs.Add(err)
+	for name, data := range db {
+		files[name] = data
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return files, nil
+}
+
+// Имена сгенерированных файлов с основным описанием библиотеки и тестовой базой данных.
+const (
+	DBFile     = "db.go"
+	DBTestFile = "db_test.go"
+)
+
+// FileName возвращает имя сгенерированного файла для файла с описанием запросов source:
+// имя файла без каталога и расширения с добавлением ".sql.go".
+func FileName(source string) string {
+	name := filepath.Base(source)
+	return name[:len(name)-len(filepath.Ext(name))] + ".sql.go"
+}
+
+// TestFileName возвращает имя сгенерированного файла с тестами для файла с описанием
+// запросов source: имя файла без каталога и расширения с добавлением "_sql_test.go".
+func TestFileName(source string) string {
+	name := filepath.Base(source)
+	return name[:len(name)-len(filepath.Ext(name))] + "_sql_test.go"
+}
+
+// generateError возвращает описание ошибки генерации кода для указанного исходного файла.
+// Ошибки с информацией о позиции в исходном файле возвращаются без изменений.
+func generateError(file string, err error) error {
+	var errs config.Errors
+	if errors.As(err, &errs) {
+		return errs
+	}
+
+	return config.Error{
+		Code:    config.CodeGenerate,
+		File:    file,
+		Message: err.Error(),
+	}
 }
 
 // generate генерирует код с использованием шаблона name и параметров data.
 // Возвращает форматированный сгенерированный код.
-func generate(name string, data any) ([]byte, error) {
+func (g Generator) generate(name string, data any) ([]byte, error) {
 	// генерируем код основного файла на основании шаблона
 	var buf bytes.Buffer
-	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+	if err := g.tmpl.ExecuteTemplate(&buf, name, data); err != nil {
 		return nil, fmt.Errorf("generate: %w", err)
 	}
 
@@ -135,46 +510,110 @@ func generate(name string, data any) ([]byte, error) {
 	return formatted, nil
 }
 
-// getImports возвращает список библиотек для импорта.
-func (g Generator) getImports(qs []config.Query) (map[string]string, error) {
+// Import описывает импортируемую библиотеку.
+type Import struct {
+	Name string // синоним пакета, если он отличается от названия пакета
+	Path string // путь импорта
+}
+
+// dbImports содержит библиотеки, которые всегда импортируются в основном файле библиотеки.
+var dbImports = []Import{{Path: "context"}, {Path: "database/sql"}, {Path: "errors"}}
+
+// getImports возвращает отсортированный список библиотек для импорта, которые используются
+// в описании запросов qs и объявлениях именованных типов types, вместе с библиотеками extra.
+// Именованные типы в описании полей используются без префикса пакета, поэтому не требуют импорта.
+func (g Generator) getImports(qs []config.Query, types config.NamedTypes, extra ...Import) ([]Import, error) {
 	// определяем, какие библиотеки нужно импортировать
-	used := make(map[string]string, len(g.imports))
+	used := make(map[Import]struct{}, len(g.imports))
+	for _, imp := range extra {
+		used[imp] = struct{}{}
+	}
 
 	// вспомогательная функция для формирования списка используемых модулей
-	getImports := func(typeName, queryName string) error {
-		if idx := strings.IndexByte(typeName, '.'); idx > 0 {
-			prefix := typeName[:idx]
-
-			if lib, ok := g.imports[prefix]; ok {
-				if strings.HasSuffix(lib, prefix) {
-					prefix = "" // не используем префикс, если он явно указан в конце пакета
-				}
-
-				used[lib] = prefix
-			} else {
-				return fmt.Errorf("unknown param package prefix %q in query %q",
-					prefix, queryName)
+	var errs config.Errors
+	getImports := func(typ string, unknown func(prefix string) error) {
+		for _, prefix := range typePrefixes(typ) {
+			lib, ok := g.imports[prefix]
+			if !ok {
+				errs.Add(unknown(prefix))
+				continue
 			}
-		}
 
-		return nil
+			if prefix == path.Base(lib) {
+				prefix = "" // не используем синоним, если префикс совпадает с последним элементом пути
+			}
+
+			used[Import{Name: prefix, Path: lib}] = struct{}{}
+		}
 	}
 
 	// проходим по всем параметрам (входящим и исходящим) всех запросов и
 	// выбираем используемые библиотеки
 	for _, q := range qs {
-		for _, t := range q.In.Fields {
-			if err := getImports(t.Type, q.Name); err != nil {
-				return nil, err
+		for _, fields := range [...][]config.Field{q.In.Fields, q.Out.Fields} {
+			for _, f := range fields {
+				getImports(f.Type, func(prefix string) error {
+					return q.FieldErrorf(f, config.CodeUnknownPackage, nil,
+						"unknown field %q type package prefix %q", f.Name, prefix)
+				})
 			}
 		}
+	}
 
-		for _, t := range q.Out.Fields {
-			if err := getImports(t.Type, q.Name); err != nil {
-				return nil, err
-			}
+	// базовые типы именованных типов и методы для работы с базой данных
+	for _, t := range types {
+		getImports(t.Type, func(prefix string) error {
+			return t.Errorf(config.CodeUnknownPackage, nil,
+				"unknown type %q package prefix %q", t.Name, prefix)
+		})
+
+		if t.Scan || t.IsEnum() {
+			used[Import{Path: "database/sql"}] = struct{}{}
+			used[Import{Path: "database/sql/driver"}] = struct{}{}
+		}
+
+		// методы перечислений возвращают ошибку для неизвестных значений
+		if t.IsEnum() {
+			used[Import{Path: "fmt"}] = struct{}{}
 		}
 	}
 
-	return used, nil
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	list := make([]Import, 0, len(used))
+	for imp := range used {
+		list = append(list, imp)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Path != list[j].Path {
+			return list[i].Path < list[j].Path
+		}
+
+		return list[i].Name < list[j].Name
+	})
+
+	return list, nil
+}
+
+// typePrefixes возвращает список префиксов пакетов, используемых в описании типа.
+func typePrefixes(typeName string) []string {
+	expr, err := config.ParseType(typeName)
+	if err != nil {
+		return nil
+	}
+
+	var prefixes []string
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			prefixes = append(prefixes, sel.X.(*ast.Ident).Name)
+			return false
+		}
+
+		return true
+	})
+
+	return prefixes
 }
@@ -0,0 +1,268 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testInputs содержит описания запросов, в которых используются типы из разных пакетов,
+// чтобы в сгенерированном коде был список импортов.
+var testInputs = map[string][]byte{
+	"queries/users.yaml": []byte(`
+types:
+  UserID: string
+
+# Return user information.
+get user:
+  type: one
+  sql: select id, name, created, comment from users where id = ?
+  in:
+    id: UserID
+  out: &user
+    id: UserID
+    name: string
+    created: time.Time
+    comment: sql.NullString
+
+# Return all users.
+list users:
+  type: many
+  sql: select id, name, created, comment from users
+  out: *user
+`),
+	"queries/events.yaml": []byte(`
+types:
+  Kind:
+    type: string
+    values:
+      created:
+      deleted:
+
+# Add a user event.
+add event:
+  type: id
+  sql: insert into events (user_id, kind, data) values (?, ?, ?)
+  in:
+    user_id: string
+    kind: Kind
+    data: json.RawMessage
+`),
+	"orders.yaml": []byte(`
+# Return the order total.
+order total:
+  type: one
+  sql: select total from orders where id = ?
+  in:
+    id: int64
+  out:
+    total: float64
+`),
+}
+
+func TestGenerateDeterministic(t *testing.T) {
+	g := newGenerator("database")
+	g.Tests = true
+
+	want, err := g.Generate(context.Background(), testInputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// порядок обхода словаря с описаниями при каждом вызове случайный
+	for i := range 5 {
+		got, err := g.Generate(context.Background(), testInputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(got) != len(want) {
+			t.Fatalf("run %d: got %d files, want %d", i, len(got), len(want))
+		}
+
+		for name, data := range want {
+			if !bytes.Equal(got[name], data) {
+				t.Errorf("run %d: file %s differs", i, name)
+			}
+		}
+	}
+}
+
+func TestGenerateImportsSorted(t *testing.T) {
+	g := newGenerator("database")
+	g.Tests = true
+
+	files, err := g.Generate(context.Background(), testInputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range files {
+		if paths := importPaths(t, name, data); !sort.StringsAreSorted(paths) {
+			t.Errorf("%s: imports are not sorted: %v", name, paths)
+		}
+	}
+
+	got := strings.Join(importPaths(t, "users.sql.go", files["users.sql.go"]), " ")
+	if want := "context database/sql time"; got != want {
+		t.Errorf("users.sql.go imports = %s, want %s", got, want)
+	}
+}
+
+// importPaths возвращает пути импорта из сгенерированного кода в порядке их объявления.
+func importPaths(t *testing.T, name string, data []byte) []string {
+	t.Helper()
+
+	f, err := parser.ParseFile(token.NewFileSet(), name, data, parser.ImportsOnly)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+
+	paths := make([]string, 0, len(f.Imports))
+	for _, imp := range f.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		paths = append(paths, path)
+	}
+
+	return paths
+}
+
+func TestGenerateSource(t *testing.T) {
+	g := newGenerator("database")
+
+	files, err := g.Generate(context.Background(), testInputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, source := range map[string]string{
+		"users.sql.go":  "queries/users.yaml",
+		"events.sql.go": "queries/events.yaml",
+		"orders.sql.go": "orders.yaml",
+	} {
+		if line := "\n// source: " + source + "\n"; !bytes.Contains(files[name], []byte(line)) {
+			t.Errorf("%s: source line %q not found", name, strings.TrimSpace(line))
+		}
+	}
+}
+
+func TestGenerateTypesOnly(t *testing.T) {
+	g := newGenerator("database")
+
+	files, err := g.Generate(context.Background(), map[string][]byte{
+		"types.yaml": []byte(`
+types:
+  UserID: string
+  Status:
+    type: string
+    values:
+      active:
+      blocked:
+`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if paths := importPaths(t, "types.sql.go", files["types.sql.go"]); slices.Contains(paths, "context") {
+		t.Errorf("types.sql.go imports context without queries: %v", paths)
+	}
+
+	typeCheck(t, files)
+}
+
+// typeCheck проверяет, что сгенерированные файлы пакета компилируются.
+func typeCheck(t *testing.T, files map[string][]byte) {
+	t.Helper()
+
+	fset := token.NewFileSet()
+	var list []*ast.File
+	for name, data := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, name, data, 0)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		list = append(list, f)
+	}
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("database", fset, list, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// packageTypesInput содержит описание запроса с типом, которого нет в пакете time.
+var packageTypesInput = map[string][]byte{
+	"events.yaml": []byte(`
+# Return the event time.
+event time:
+  type: one
+  sql: select created from events where id = ?
+  in:
+    id: int64
+  out:
+    created: time.Moment
+`),
+}
+
+func TestGeneratePackagesDisabled(t *testing.T) {
+	g, err := NewWithOptions(Options{Package: "database"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// без загрузки описаний пакетов типы из них не проверяются
+	if _, err := g.Generate(context.Background(), packageTypesInput); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGeneratePackagesLoader(t *testing.T) {
+	fset := token.NewFileSet()
+	imp := importer.ForCompiler(fset, "source", nil)
+
+	var loaded []string
+	g, err := NewWithOptions(Options{
+		Package: "database",
+		Packages: func(paths ...string) (map[string]*types.Package, error) {
+			pkgs := make(map[string]*types.Package, len(paths))
+			for _, path := range paths {
+				pkg, err := imp.Import(path)
+				if err != nil {
+					return nil, err
+				}
+
+				loaded = append(loaded, path)
+				pkgs[path] = pkg
+			}
+
+			return pkgs, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = g.Generate(context.Background(), packageTypesInput)
+	if err == nil || !strings.Contains(err.Error(), "time.Moment not defined") {
+		t.Fatalf("error = %v, want time.Moment not defined", err)
+	}
+
+	if !slices.Contains(loaded, "time") {
+		t.Errorf("loaded packages = %v, want time", loaded)
+	}
+}
@@ -0,0 +1,241 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/mdigger/sqlgen/config"
+)
+
+// reservedFuncNames содержит названия методов, которые уже определены для Queries.
+var reservedFuncNames = map[string]bool{
+	"WithTx": true,
+}
+
+// reservedTypeNames содержит названия, которые уже определены в основном файле библиотеки.
+var reservedTypeNames = map[string]bool{
+	"Queries":           true,
+	"New":               true,
+	"ErrTxNotSupported": true,
+	"ErrNoRows":         true,
+}
+
+// FuncName возвращает название функции, которая будет сгенерирована для запроса.
+func (g Generator) FuncName(q config.Query) string {
+	return g.namer.funcName(q)
+}
+
+// checkNames проверяет, что названия функций запросов, полей структур и именованных типов types
+// не пересекаются между собой и с уже определёнными в библиотеке названиями.
+func (g Generator) checkNames(qs []config.Query, types config.NamedTypes) error {
+	var errs config.Errors
+
+	// названия структур параметров не должны совпадать с названиями именованных типов
+	structs := make(map[string]config.Query) // название структуры -> запрос
+	for _, q := range qs {
+		for _, name := range g.structNames(q) {
+			structs[name] = q
+		}
+	}
+
+	// названия типов и констант из настроек проекта проверяются при генерации основного файла
+	declared := make(map[string]string) // название -> описание
+	for _, t := range g.NamedTypes {
+		for _, name := range g.typeNames(t) {
+			declared[name] = "the project configuration"
+			if q, ok := structs[name]; ok {
+				errs.Add(q.Errorf(config.CodeNameConflict, nil,
+					"parameters type name %s conflicts with the project configuration", name))
+			}
+		}
+	}
+
+	for _, t := range types {
+		errs = append(errs, g.checkTypeNames(t, structs, declared)...)
+	}
+
+	funcs := make(map[string]string, len(qs)) // название функции -> название запроса
+	for _, q := range qs {
+		name := g.namer.funcName(q)
+		if reservedFuncNames[name] {
+			errs.Add(q.Errorf(config.CodeNameConflict, nil, "function name %s is reserved", name))
+		} else if other, ok := funcs[name]; ok {
+			errs.Add(q.Errorf(config.CodeNameConflict, nil, "function name %s conflicts with query %q", name, other))
+		} else {
+			funcs[name] = q.Name
+		}
+
+		for _, fields := range [...][]config.Field{q.In.Fields, q.Out.Fields} {
+			names := make(map[string]string, len(fields)) // название поля структуры -> название поля
+			for _, f := range fields {
+				name := g.namer.fieldName(f)
+				if other, ok := names[name]; ok {
+					errs.Add(q.FieldErrorf(f, config.CodeNameConflict, nil,
+						"field name %s conflicts with field %q", name, other))
+				} else {
+					names[name] = f.Name
+				}
+			}
+		}
+	}
+
+	return errs.Err()
+}
+
+// structNames возвращает названия структур, которые генерируются для параметров запроса.
+// Названия формируются так же, как в шаблонах "params in type" и "params out type".
+func (g Generator) structNames(q config.Query) []string {
+	var names []string
+	for _, p := range [...]struct {
+		fields *config.Fields
+		suffix string
+	}{{&q.In, "Params"}, {&q.Out, "Out"}} {
+		switch {
+		case p.fields.Alias != "" || len(p.fields.Fields) < 2:
+		case p.fields.Anchor != "":
+			names = append(names, g.namer.publicName(p.fields.Anchor))
+		default:
+			names = append(names, g.namer.funcName(q)+p.suffix)
+		}
+	}
+
+	return names
+}
+
+// typeNames возвращает названия, которые объявляются для именованного типа t: название типа
+// и названия констант значений перечисления.
+func (g Generator) typeNames(t config.NamedType) []string {
+	names := []string{t.Name}
+	for _, v := range t.Values {
+		names = append(names, g.namer.enumName(t, v))
+	}
+
+	return names
+}
+
+// checkTypeNames проверяет, что названия, которые объявляются для именованного типа t, не совпадают
+// с уже определёнными в библиотеке названиями, названиями структур параметров запросов structs
+// и ранее объявленными названиями declared (название -> описание), и добавляет их в declared.
+func (g Generator) checkTypeNames(t config.NamedType, structs map[string]config.Query, declared map[string]string) config.Errors {
+	var errs config.Errors
+	check := func(name string, errorf func(config.Code, error, string, ...any) error) {
+		q, isStruct := structs[name]
+		switch other := declared[name]; {
+		case reservedTypeNames[name]:
+			errs.Add(errorf(config.CodeNameConflict, nil, "name %s is reserved", name))
+		case isStruct:
+			errs.Add(errorf(config.CodeNameConflict, nil, "name %s conflicts with parameters of query %q", name, q.Name))
+		case other != "":
+			errs.Add(errorf(config.CodeNameConflict, nil, "name %s conflicts with %s", name, other))
+		default:
+			declared[name] = fmt.Sprintf("type %q", t.Name)
+		}
+	}
+
+	check(t.Name, t.Errorf)
+	for _, v := range t.Values {
+		check(g.namer.enumName(t, v), v.Errorf)
+	}
+
+	return errs
+}
+
+// Names описывает названия, которые объявляются в файле запросов библиотеки.
+type Names struct {
+	Funcs []string `json:"funcs,omitempty"` // названия методов запросов в порядке запросов
+	Types []string `json:"types,omitempty"` // названия типов, констант и структур параметров
+}
+
+// Names возвращает названия, которые объявляются в коде, сгенерированном для описания
+// запросов qs.
+func (g Generator) Names(qs *config.Queries) Names {
+	var names Names
+	for _, q := range qs.Queries {
+		names.Funcs = append(names.Funcs, g.namer.funcName(q))
+		names.Types = append(names.Types, g.structNames(q)...)
+	}
+
+	for _, t := range qs.Types {
+		names.Types = append(names.Types, g.typeNames(t)...)
+	}
+
+	return names
+}
+
+// Declarations проверяет, что названия, которые объявляются в разных файлах одной библиотеки,
+// не пересекаются между собой. Пересечения названий внутри одного файла проверяются при
+// генерации его кода ([Generator.Query]). Нулевое значение готово к использованию.
+type Declarations struct {
+	funcs map[string]string // название метода -> исходный файл
+	types map[string]string // название уровня пакета -> исходный файл
+}
+
+// Declare добавляет названия, которые объявляются в коде для описания запросов qs из файла
+// source, и возвращает ошибки с позициями названий, если они уже объявлены в других файлах.
+func (g Generator) Declare(d *Declarations, source string, qs *config.Queries) error {
+	var errs config.Errors
+	for _, q := range qs.Queries {
+		errs.Add(d.declareFunc(source, g.namer.funcName(q), q.Errorf))
+		for _, name := range g.structNames(q) {
+			errs.Add(d.declareType(source, name, q.Errorf))
+		}
+	}
+
+	for _, t := range qs.Types {
+		errs.Add(d.declareType(source, t.Name, t.Errorf))
+		for _, v := range t.Values {
+			errs.Add(d.declareType(source, g.namer.enumName(t, v), v.Errorf))
+		}
+	}
+
+	return errs.Err()
+}
+
+// Add добавляет названия names, которые объявляются в файле source, и возвращает ошибки,
+// если они уже объявлены в других файлах. Используется, когда описание запросов не разбиралось
+// (например, код взят из кеша), поэтому ошибки относятся ко всему файлу.
+func (d *Declarations) Add(source string, names Names) error {
+	errorf := func(code config.Code, _ error, format string, args ...any) error {
+		return config.Error{Code: code, File: source, Message: fmt.Sprintf(format, args...)}
+	}
+
+	var errs config.Errors
+	for _, name := range names.Funcs {
+		errs.Add(d.declareFunc(source, name, errorf))
+	}
+
+	for _, name := range names.Types {
+		errs.Add(d.declareType(source, name, errorf))
+	}
+
+	return errs.Err()
+}
+
+// declareFunc добавляет название метода запроса name из файла source.
+func (d *Declarations) declareFunc(source, name string, errorf func(config.Code, error, string, ...any) error) error {
+	if d.funcs == nil {
+		d.funcs = make(map[string]string)
+	}
+
+	if other, ok := d.funcs[name]; ok && other != source {
+		return errorf(config.CodeNameConflict, nil, "function name %s already defined in %q", name, other)
+	}
+
+	d.funcs[name] = source
+
+	return nil
+}
+
+// declareType добавляет название типа, константы или структуры параметров name из файла source.
+func (d *Declarations) declareType(source, name string, errorf func(config.Code, error, string, ...any) error) error {
+	if d.types == nil {
+		d.types = make(map[string]string)
+	}
+
+	if other, ok := d.types[name]; ok && other != source {
+		return errorf(config.CodeNameConflict, nil, "name %s already declared in %q", name, other)
+	}
+
+	d.types[name] = source
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package generator
+
+import (
+	"fmt"
+	"io/fs"
+
+	"github.com/mdigger/sqlgen/config"
+	"github.com/mdigger/sqlgen/schema"
+)
+
+// Options описывает параметры генератора для использования в качестве библиотеки.
+type Options struct {
+	// Package задаёт название пакета сгенерированной библиотеки. Если название
+	// не задано, то используется "database".
+	Package string
+	// Dialect задаёт диалект SQL для проверки текстов запросов.
+	Dialect config.Dialect
+	// Imports содержит дополнительные пакеты для импорта в виде пути импорта или
+	// префикса и пути, разделённых двоеточием ("uuid:github.com/gofrs/uuid").
+	Imports []string
+	// Initialisms содержит дополнительный список аббревиатур, которые в сгенерированных
+	// названиях записываются заглавными буквами.
+	Initialisms []string
+	// Tests включает генерацию тестов запросов с тестовой базой данных.
+	Tests bool
+	// Templates задаёт файлы *.tmpl, шаблоны из которых заменяют стандартные
+	// шаблоны генерации кода с тем же названием. Если не задано, то используются
+	// только стандартные шаблоны.
+	Templates fs.FS
+	// Schema задаёт структуру базы данных для определения исходящих параметров запросов,
+	// которые не описаны явно. Структура не закрывается генератором.
+	Schema *schema.Schema
+	// Types задаёт соответствие типов колонок SQL типам golang. Если не задано,
+	// то используется [schema.DefaultTypes].
+	Types schema.TypeMap
+	// SQLTypes задаёт дополнительные соответствия типов колонок SQL типам golang
+	// для диалекта Dialect (см. [Generator.SetTypes]).
+	SQLTypes []config.SQLType
+	// NamedTypes задаёт именованные типы, которые объявляются в основном файле библиотеки
+	// и могут использоваться в описании запросов из всех файлов.
+	NamedTypes config.NamedTypes
+	// Packages задаёт загрузку описаний пакетов для проверки типов (см. [Generator.Packages]).
+	// Если не задано, то типы из пакетов не проверяются, а генератор не обращается
+	// к файловой системе; для загрузки пакетов через "go list" используется [GoPackages].
+	Packages PackageLoader
+}
+
+// NewWithOptions возвращает новый генератор с заданными параметрами.
+// В отличие от [New], не использует текущий каталог для определения названия пакета.
+func NewWithOptions(opts Options) (*Generator, error) {
+	name := opts.Package
+	if name == "" {
+		name = "database"
+	}
+
+	if !opts.Dialect.Valid() {
+		return nil, fmt.Errorf("unsupported dialect %q", opts.Dialect)
+	}
+
+	g := newGenerator(name, opts.Imports...)
+	g.Dialect = opts.Dialect
+	g.Tests = opts.Tests
+	g.Schema = opts.Schema
+	g.Types = opts.Types
+	g.NamedTypes = opts.NamedTypes
+	g.Packages = opts.Packages
+	g.SetInitialisms(opts.Initialisms...)
+	if len(opts.SQLTypes) > 0 {
+		if err := g.SetTypes(opts.SQLTypes...); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Templates != nil {
+		if err := g.SetTemplates(opts.Templates); err != nil {
+			return nil, err
+		}
+	}
+
+	return &g, nil
+}
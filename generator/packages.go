@@ -0,0 +1,122 @@
+package generator
+
+import (
+	"fmt"
+	"go/types"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// PackageLoader загружает описания типов пакетов с указанными путями импорта и возвращает
+// их по путям импорта. Используется для проверки типов параметров запросов, которые
+// заданы с префиксом пакета.
+type PackageLoader func(paths ...string) (map[string]*types.Package, error)
+
+// GoPackages возвращает загрузку описаний пакетов через "go list" из кеша модулей или
+// каталога vendor текущего модуля без обращения к сети. Загруженные пакеты кешируются,
+// поэтому одну загрузку можно использовать в нескольких генераторах. Для работы нужен
+// установленный golang.
+func GoPackages() PackageLoader {
+	return new(packageCache).load
+}
+
+// packageCache хранит информацию о загруженных пакетах, чтобы не загружать их повторно.
+type packageCache struct {
+	mu   sync.Mutex
+	pkgs map[string]*packages.Package // загруженные пакеты по пути импорта
+}
+
+// config возвращает настройки загрузки пакетов.
+// Пакеты загружаются без обращения к сети: только из кеша модулей или vendor.
+func (c *packageCache) config(mode packages.LoadMode) *packages.Config {
+	return &packages.Config{
+		Mode: mode,
+		Env:  append(os.Environ(), "GOPROXY=off", "GOTOOLCHAIN=local"),
+	}
+}
+
+// load загружает описание типов указанных пакетов и возвращает их.
+func (c *packageCache) load(paths ...string) (map[string]*types.Package, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.pkgs == nil {
+		c.pkgs = make(map[string]*packages.Package)
+	}
+
+	// выбираем пакеты, которые ещё не были загружены
+	var missing []string
+	for _, path := range paths {
+		if _, ok := c.pkgs[path]; !ok {
+			missing = append(missing, path)
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		list, err := packages.Load(c.config(packages.NeedName|packages.NeedTypes), missing...)
+		if err != nil {
+			return nil, fmt.Errorf("load packages: %w", err)
+		}
+
+		for _, pkg := range list {
+			c.pkgs[pkg.PkgPath] = pkg
+		}
+	}
+
+	result := make(map[string]*types.Package, len(paths))
+	for _, path := range paths {
+		pkg, ok := c.pkgs[path]
+		if !ok {
+			return nil, fmt.Errorf("package %q not loaded", path)
+		}
+
+		if len(pkg.Errors) > 0 {
+			return nil, fmt.Errorf("package %q: %w", path, pkg.Errors[0])
+		}
+
+		result[path] = pkg.Types
+	}
+
+	return result, nil
+}
+
+// packageName возвращает название пакета по пути его импорта так же, как его предполагает
+// goimports: учитываются суффикс с основной версией модуля (/v5), соглашения gopkg.in
+// (yaml.v3) и префиксы go- и go. в названии репозитория (go-sqlite3, go.uuid). Название
+// не зависит от содержимого кеша модулей, поэтому сгенерированный код одинаков на любом
+// компьютере. Если настоящее название пакета отличается, то префикс задаётся явно.
+func packageName(importPath string) string {
+	name := path.Base(importPath)
+
+	// суффикс с основной версией модуля: используем предыдущий элемент пути
+	if len(name) > 1 && name[0] == 'v' {
+		if _, err := strconv.Atoi(name[1:]); err == nil {
+			if dir := path.Dir(importPath); dir != "." {
+				name = path.Base(dir)
+			}
+		}
+	}
+
+	if trimmed, ok := strings.CutPrefix(name, "go-"); ok && trimmed != "" {
+		name = trimmed
+	} else if trimmed, ok := strings.CutPrefix(name, "go."); ok && trimmed != "" {
+		name = trimmed
+	}
+
+	// отбрасываем всё, начиная с первого символа, недопустимого в идентификаторе (yaml.v3, uuid-go)
+	if idx := strings.IndexFunc(name, func(r rune) bool {
+		return r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}); idx >= 0 {
+		name = name[:idx]
+	}
+
+	return name
+}
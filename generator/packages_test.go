@@ -0,0 +1,22 @@
+package generator
+
+import "testing"
+
+func TestPackageName(t *testing.T) {
+	for path, want := range map[string]string{
+		"time":                        "time",
+		"database/sql":                "sql",
+		"github.com/google/uuid":      "uuid",
+		"github.com/satori/go.uuid":   "uuid",
+		"github.com/mattn/go-sqlite3": "sqlite3",
+		"github.com/jackc/pgx/v5":     "pgx",
+		"gopkg.in/yaml.v3":            "yaml",
+		"github.com/gofrs/uuid/v5":    "uuid",
+		"example.com/uuid-go":         "uuid",
+		"example.com/go":              "go",
+	} {
+		if got := packageName(path); got != want {
+			t.Errorf("packageName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"strconv"
+	"strings"
+
+	"github.com/mdigger/sqlgen/config"
+)
+
+// testValues формирует значения для сгенерированных тестов с учётом именованных типов
+// библиотеки.
+type testValues struct {
+	namer namer                       // формирование названий констант перечислений
+	types map[string]config.NamedType // именованные типы по названию
+}
+
+// testValue возвращает выражение golang со значением типа typ для сгенерированных тестов
+// без учёта именованных типов библиотеки.
+func testValue(typ, name string, index int) string {
+	return testValues{}.value(typ, name, index)
+}
+
+// value возвращает выражение golang со значением типа typ для сгенерированных тестов.
+// Для поддерживаемых типов значения зависят от названия поля name и его порядкового номера
+// index, чтобы значения разных параметров отличались и по ним можно было проверить их порядок.
+// Для остальных типов возвращается пустое значение.
+func (tv testValues) value(typ, name string, index int) string {
+	expr, err := config.ParseType(typ)
+	if err != nil {
+		return "*new(" + typ + ")"
+	}
+
+	return tv.expr(expr, typ, name, index+1)
+}
+
+// expr возвращает выражение golang со значением для разобранного описания типа.
+func (tv testValues) expr(expr ast.Expr, typ, name string, n int) string {
+	text := strconv.Quote(fmt.Sprintf("%s %d", name, n))
+	switch expr := expr.(type) {
+	case *ast.ParenExpr:
+		return tv.expr(expr.X, typ, name, n)
+
+	case *ast.Ident:
+		// для перечисления используется первое допустимое значение, а значение остальных
+		// именованных типов преобразуется из значения базового типа
+		if t, ok := tv.types[expr.Name]; ok {
+			if t.IsEnum() {
+				return tv.namer.enumName(t, t.Values[0])
+			}
+
+			if base, err := config.ParseType(t.Type); err == nil {
+				if value := tv.expr(base, t.Type, name, n); !strings.HasPrefix(value, "*new(") {
+					return expr.Name + "(" + value + ")"
+				}
+			}
+
+			break
+		}
+
+		switch expr.Name {
+		case "string":
+			return text
+		case "bool":
+			return "true"
+		case "int", "int8", "int16", "int32", "int64",
+			"uint", "uint8", "uint16", "uint32", "uint64", "byte", "rune":
+			return fmt.Sprintf("%s(%d)", expr.Name, n)
+		case "float32", "float64":
+			return fmt.Sprintf("%s(%d.5)", expr.Name, n)
+		case "any":
+			return text
+		}
+
+	case *ast.InterfaceType:
+		return text
+
+	case *ast.StarExpr:
+		elem := typ[1:] // тип без указателя
+		return "fakePtr(" + tv.expr(expr.X, elem, name, n) + ")"
+
+	case *ast.ArrayType:
+		if ident, ok := expr.Elt.(*ast.Ident); ok && expr.Len == nil &&
+			(ident.Name == "byte" || ident.Name == "uint8") {
+			return "[]byte(" + text + ")"
+		}
+
+	case *ast.SelectorExpr:
+		pkg := expr.X.(*ast.Ident).Name
+		switch pkg + "." + expr.Sel.Name {
+		case "time.Time":
+			return fmt.Sprintf("%[1]s.Date(2001, 2, 3, 4, 5, %[2]d, 0, %[1]s.UTC)", pkg, n)
+		case "json.RawMessage":
+			return fmt.Sprintf("%s.RawMessage(%s)", pkg, strconv.Quote(strconv.Quote(name)))
+		case "sql.NullString":
+			return fmt.Sprintf("%s.NullString{String: %s, Valid: true}", pkg, text)
+		case "sql.NullBool":
+			return fmt.Sprintf("%s.NullBool{Bool: true, Valid: true}", pkg)
+		case "sql.NullByte":
+			return fmt.Sprintf("%s.NullByte{Byte: %d, Valid: true}", pkg, n)
+		case "sql.NullInt16":
+			return fmt.Sprintf("%s.NullInt16{Int16: %d, Valid: true}", pkg, n)
+		case "sql.NullInt32":
+			return fmt.Sprintf("%s.NullInt32{Int32: %d, Valid: true}", pkg, n)
+		case "sql.NullInt64":
+			return fmt.Sprintf("%s.NullInt64{Int64: %d, Valid: true}", pkg, n)
+		case "sql.NullFloat64":
+			return fmt.Sprintf("%s.NullFloat64{Float64: %d.5, Valid: true}", pkg, n)
+		case "sql.NullTime":
+			return fmt.Sprintf("%s.NullTime{Valid: true}", pkg) // пакет time может быть не импортирован
+		}
+	}
+
+	return "*new(" + typ + ")"
+}
@@ -0,0 +1,135 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mdigger/sqlgen/config"
+	"github.com/mdigger/sqlgen/schema"
+)
+
+// SetTypes задаёт соответствие типов колонок SQL типам golang в дополнение к соответствию
+// [Generator.Types] (или [schema.DefaultTypes], если оно не задано). Используются только
+// соответствия для диалекта [Generator.Dialect] и соответствия без диалекта, причём
+// соответствия для диалекта имеют приоритет.
+//
+// Пакеты типов, заданных с полным путём импорта ("github.com/google/uuid.UUID"), добавляются
+// в список поддерживаемых пакетов, поэтому их можно использовать и в описании полей без
+// явного импорта. Метод нужно вызывать после задания диалекта и нельзя вызывать одновременно
+// с генерацией кода.
+func (g *Generator) SetTypes(types ...config.SQLType) error {
+	// выбираем соответствия для диалекта, которые заменяют соответствия без диалекта
+	var selected []config.SQLType
+	for _, t := range types {
+		if t.Dialect == config.DialectAny {
+			selected = append(selected, t)
+		}
+	}
+
+	for _, t := range types {
+		if t.Dialect != config.DialectAny && t.Dialect == g.Dialect {
+			selected = append(selected, t)
+		}
+	}
+
+	base := g.Types
+	if base == nil {
+		base = schema.DefaultTypes()
+	}
+
+	m := make(schema.TypeMap, len(base)+len(selected))
+	for name, t := range base {
+		m[name] = t
+	}
+
+	for _, t := range selected {
+		goType, err := g.qualifyType(t.Go)
+		if err != nil {
+			return fmt.Errorf("sql type %q: %w", t.SQL, err)
+		}
+
+		nullable, err := g.qualifyType(t.Nullable)
+		if err != nil {
+			return fmt.Errorf("sql type %q: %w", t.SQL, err)
+		}
+
+		m.Set(t.SQL, schema.GoType{Type: goType, Nullable: nullable})
+	}
+
+	g.Types = m
+
+	return nil
+}
+
+// qualifyType заменяет в описании типа полный путь импорта пакета на его префикс и добавляет
+// пакет в список поддерживаемых. Типы без пути импорта возвращаются без изменений.
+func (g *Generator) qualifyType(typ string) (string, error) {
+	if typ == "" {
+		return "", nil
+	}
+
+	modifiers, lib, name := splitImport(typ)
+	if lib != "" {
+		prefix, err := g.addImport(lib)
+		if err != nil {
+			return "", err
+		}
+
+		typ = modifiers + prefix + "." + name
+	}
+
+	if _, err := config.ParseType(typ); err != nil {
+		return "", err
+	}
+
+	return typ, nil
+}
+
+// addImport добавляет пакет в список поддерживаемых и возвращает его префикс.
+func (g *Generator) addImport(lib string) (string, error) {
+	// пакет уже поддерживается, возможно, с другим префиксом: если префиксов несколько,
+	// то выбираем название пакета или первый по алфавиту, чтобы результат не зависел
+	// от порядка обхода словаря
+	prefix := packageName(lib)
+	if g.imports[prefix] == lib {
+		return prefix, nil
+	}
+
+	var prefixes []string
+	for other, path := range g.imports {
+		if path == lib {
+			prefixes = append(prefixes, other)
+		}
+	}
+
+	if len(prefixes) > 0 {
+		return slices.Min(prefixes), nil
+	}
+
+	if other, ok := g.imports[prefix]; ok {
+		return "", fmt.Errorf("package %q prefix %q already used by %q", lib, prefix, other)
+	}
+
+	g.imports[prefix] = lib
+
+	return prefix, nil
+}
+
+// splitImport разделяет описание типа с полным путём импорта пакета на модификаторы типа
+// (указатель, срез), путь импорта и название типа: "*github.com/google/uuid.UUID" ->
+// "*", "github.com/google/uuid", "UUID". Если путь импорта не задан, то возвращается
+// пустой путь.
+func splitImport(typ string) (modifiers, lib, name string) {
+	rest := strings.TrimLeft(typ, "*[]")
+	modifiers = typ[:len(typ)-len(rest)]
+
+	// название типа следует за последней точкой, а путь импорта может содержать точки
+	// и в последнем элементе ("gopkg.in/yaml.v3.Node")
+	slash, dot := strings.LastIndexByte(rest, '/'), strings.LastIndexByte(rest, '.')
+	if slash < 0 || dot < slash {
+		return modifiers, "", rest
+	}
+
+	return modifiers, rest[:dot], rest[dot+1:]
+}
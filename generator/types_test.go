@@ -0,0 +1,17 @@
+package generator
+
+import "testing"
+
+func TestAddImportDeterministic(t *testing.T) {
+	for range 20 {
+		g := newGenerator("database", "u:github.com/google/uuid", "v:github.com/google/uuid", "w:github.com/google/uuid")
+		prefix, err := g.addImport("github.com/google/uuid")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if prefix != "u" {
+			t.Fatalf("prefix = %q, want %q", prefix, "u")
+		}
+	}
+}
@@ -1,15 +1,29 @@
 module github.com/mdigger/sqlgen
 
-go 1.19
+go 1.25.0
 
 require (
 	github.com/mdigger/wordwrap v1.0.0
 	github.com/urfave/cli/v3 v3.0.0-alpha
+	golang.org/x/tools v0.47.0
 	gopkg.in/yaml.v3 v3.0.1
+	modernc.org/libc v1.66.10
+	modernc.org/sqlite v1.40.1
 )
 
 require (
 	github.com/cpuguy83/go-md2man/v2 v2.0.2 // indirect
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	github.com/xrash/smetrics v0.0.0-20201216005158-039620a65673 // indirect
+	golang.org/x/exp v0.0.0-20250620022241-b7579e27df2b // indirect
+	golang.org/x/mod v0.37.0 // indirect
+	golang.org/x/sync v0.21.0 // indirect
+	golang.org/x/sys v0.46.0 // indirect
+	modernc.org/mathutil v1.7.1 // indirect
+	modernc.org/memory v1.11.0 // indirect
 )
@@ -0,0 +1,137 @@
+package main
+
+import (
+	_ "embed" // use embedded starter files
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mdigger/sqlgen/config"
+	"github.com/mdigger/sqlgen/generator"
+	"github.com/urfave/cli/v3"
+)
+
+// starterQueries содержит пример описания запросов всех типов для новой библиотеки.
+//
+//go:embed example/users.yaml
+var starterQueries []byte
+
+// starterQueriesFile задаёт имя файла с примером описания запросов.
+const starterQueriesFile = "queries.yaml"
+
+// starterProject содержит пример файла с настройками проекта.
+const starterProject = `# sqlgen project configuration.
+
+# Additional initialisms written in upper case in the generated names
+# (ID, URL, HTTP, JSON and other standard golang initialisms are always used).
+initialisms: []
+
+# Named types declared in the main file of the generated package and used
+# in the query descriptions by name.
+#
+# types:
+#   UserID: string
+
+# Additional mapping of SQL column types to golang types used to infer
+# outgoing parameters from the database schema.
+#
+# sql_types:
+#   - sql: uuid
+#     go: github.com/google/uuid.UUID
+#     nullable: github.com/google/uuid.NullUUID
+
+# Several libraries can be generated in one run. Relative paths are resolved
+# against this file directory. Without targets, the query files and flags
+# from the command line are used.
+#
+# targets:
+#   - name: users
+#     sources: [users/*.yaml]
+#     out: users/db
+#     package: db
+#     imports: [github.com/gofrs/uuid]
+#     dialect: postgres
+#     schema: [users/schema.sql]
+`
+
+// starterDoc содержит шаблон файла с описанием пакета и директивой go:generate.
+const starterDoc = `// Package %[1]s contains SQL queries library generated by sqlgen from the query
+// descriptions in *.yaml files. Run "go generate" after changing them.
+package %[1]s
+
+//go:generate sqlgen generate
+`
+
+// initCmd создаёт в каталоге заготовку новой библиотеки запросов: пример описания запросов,
+// файл с настройками проекта и файл с описанием пакета и директивой go:generate.
+// Существующие файлы не перезаписываются.
+func initCmd(c *cli.Context) error {
+	if c.Args().Len() > 1 {
+		return errors.New("only one directory can be set")
+	}
+
+	dir := c.Args().First()
+	if dir == "" {
+		dir = "."
+	}
+
+	pkg := generator.New(dir).Package
+	files := []generatedFile{
+		{Name: filepath.Join(dir, starterQueriesFile), Data: starterQueries},
+		{Name: filepath.Join(dir, config.ProjectFile), Data: []byte(starterProject)},
+		{Name: filepath.Join(dir, "doc.go"), Data: fmt.Appendf(nil, starterDoc, pkg)},
+	}
+
+	// проверяем, что ни один из файлов ещё не существует
+	var exist []string
+	for _, file := range files {
+		if _, err := os.Stat(file.Name); err == nil {
+			exist = append(exist, file.Name)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	if len(exist) > 0 {
+		return fmt.Errorf("files already exist: %s", strings.Join(exist, ", "))
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("create folder %q: %w", dir, err)
+	}
+
+	for _, file := range files {
+		if err := createFile(file); err != nil {
+			return err
+		}
+
+		log.Println("created:", file.Name)
+	}
+
+	log.Printf("run \"go generate\" in %s to generate the library", dir)
+
+	return nil
+}
+
+// createFile создаёт новый файл с заданным содержимым. Если файл уже существует,
+// то возвращается ошибка.
+func createFile(file generatedFile) error {
+	f, err := os.OpenFile(file.Name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("create file %q: %w", file.Name, err)
+	}
+
+	if _, err := f.Write(file.Data); err != nil {
+		f.Close()
+		return fmt.Errorf("create file %q: %w", file.Name, err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("create file %q: %w", file.Name, err)
+	}
+
+	return nil
+}
@@ -1,11 +1,12 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
-	"path/filepath"
 
 	"github.com/mdigger/sqlgen/config"
 	"github.com/mdigger/sqlgen/generator"
@@ -27,24 +28,55 @@ func main() {
 			Name:        "generate",
 			Usage:       "Generate Golang library",
 			Description: helpString(generateDescription),
-			Action:      generateCmd,
-			Flags: []cli.Flag{
-				&cli.PathFlag{
-					Name:    "out",
-					Usage:   "`path` to writing",
-					Aliases: []string{"o"},
+			Action:      withDiagnostics(generateCmd),
+			Flags: append([]cli.Flag{
+				&cli.BoolFlag{
+					Name:  "verify",
+					Usage: "compare generated code with existing files instead of writing",
 				},
-				&cli.StringFlag{
-					Name:    "name",
-					Usage:   "set `library` name",
-					Aliases: []string{"n"},
+				&cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "list files to be written and removed without changing anything",
 				},
-				&cli.StringSliceFlag{
-					Name:    "import",
-					Usage:   "import `package`",
-					Aliases: []string{"i"},
+				&cli.BoolFlag{
+					Name:  "no-cache",
+					Usage: "regenerate all files ignoring the generation cache",
 				},
-			},
+				&cli.BoolFlag{
+					Name:    "watch",
+					Usage:   "watch query files and project configuration and regenerate on changes",
+					Aliases: []string{"w"},
+				},
+				&cli.DurationFlag{
+					Name:  "interval",
+					Usage: "polling `interval` for watch mode",
+					Value: defaultWatchInterval,
+				},
+			}, generateFlags...),
+		}, {
+			Name:        "check",
+			Usage:       "Check query descriptions without writing files",
+			Description: helpString(checkDescription),
+			Action:      withDiagnostics(checkCmd),
+			Flags:       generateFlags,
+		}, {
+			Name:        "vet",
+			Usage:       "Check queries against the database schema",
+			ArgsUsage:   "[files]",
+			Description: helpString(vetDescription),
+			Action:      withDiagnostics(vetCmd),
+			Flags:       vetFlags,
+		}, {
+			Name:        "init",
+			Usage:       "Create a starter query library",
+			ArgsUsage:   "[dir]",
+			Description: helpString(initDescription),
+			Action:      initCmd,
+		}, {
+			Name:        "templates",
+			Usage:       "Print the default code templates",
+			Description: helpString(templatesDescription),
+			Action:      templatesCmd,
 			// }, {
 			// 	Name:  "format",
 			// 	Usage: "Format source query files",
@@ -63,101 +95,268 @@ func main() {
 	}
 }
 
+// generateFlags содержит флаги, общие для команд генерации и проверки.
+var generateFlags = []cli.Flag{
+	&cli.PathFlag{
+		Name:    "out",
+		Usage:   "`path` to writing",
+		Aliases: []string{"o"},
+	},
+	&cli.StringFlag{
+		Name:    "name",
+		Usage:   "set `library` name",
+		Aliases: []string{"n"},
+	},
+	&cli.StringSliceFlag{
+		Name:    "import",
+		Usage:   "import `package`",
+		Aliases: []string{"i"},
+	},
+	&cli.PathFlag{
+		Name:    "config",
+		Usage:   "project configuration `file` (default: " + config.ProjectFile + ", if exists)",
+		Aliases: []string{"c"},
+	},
+	&cli.StringSliceFlag{
+		Name:    "target",
+		Usage:   "process only the `target` with the given name from the project configuration",
+		Aliases: []string{"t"},
+	},
+	&cli.BoolFlag{
+		Name:  "tests",
+		Usage: "generate *_sql_test.go files with a test for every query",
+	},
+	&cli.PathFlag{
+		Name:  "templates",
+		Usage: "`dir` with *.tmpl files overriding the default code templates",
+	},
+	&cli.StringFlag{
+		Name:  "dialect",
+		Usage: "SQL `dialect` of the queries: postgres, mysql or sqlite",
+	},
+	&cli.StringSliceFlag{
+		Name:    "schema",
+		Usage:   "SQL `file` with the database schema (DDL) to infer undescribed outgoing parameters",
+		Aliases: []string{"s"},
+	},
+	&cli.IntFlag{
+		Name:    "jobs",
+		Usage:   "`number` of query files processed in parallel (default: number of CPUs)",
+		Aliases: []string{"j"},
+	},
+	diagnosticsFlag,
+}
+
 // generateCmd выполняет команду генерации кода библиотеки.
-func generateCmd(c *cli.Context) error {
-	// список аргументов для выполнения команды
-	args := c.Args().Slice()
-	if len(args) == 0 {
-		args = []string{"*.yaml"}
-	}
-
-	// формируем список файлов с описанием запросов
-	files := make(map[string]struct{})
-	for _, arg := range args {
-		// добавляем маску для выбора файлов, если не указан конкретный файл
-		if filepath.Ext(arg) == "" {
-			arg = filepath.Join(arg, "*.yaml")
+func generateCmd(c *cli.Context, problems *config.Errors) error {
+	// отслеживаем изменения файлов и повторяем генерацию
+	if c.Bool("watch") {
+		if c.Bool("verify") || c.Bool("dry-run") {
+			return errors.New(`flag "watch" can't be used with "verify" or "dry-run"`)
 		}
 
-		// получаем список имен файлов для обработки
-		matches, err := filepath.Glob(arg)
+		return watchCmd(c)
+	}
+
+	return generate(c, problems)
+}
+
+// generate выполняет генерацию кода библиотеки и запись файлов для всех целей генерации.
+func generate(c *cli.Context, problems *config.Errors) error {
+	// при проверке весь код формируется заново, без использования кеша
+	cached := !c.Bool("verify") && !c.Bool("no-cache")
+	results, err := buildAll(c, problems, cached)
+	if err != nil {
+		return err
+	}
+
+	// если генерация хотя бы одного файла завершилась с ошибкой, ничего не записываем
+	if problems.HasErrors() {
+		return errProblems
+	}
+
+	// сравниваем сгенерированный код с существующими файлами вместо записи
+	if c.Bool("verify") {
+		return verify(results, problems)
+	}
+
+	// ищем ранее сгенерированные файлы, описания которых больше не входят в исходные файлы
+	orphans, err := orphansByTarget(results)
+	if err != nil {
+		return err
+	}
+
+	// выводим список изменений без записи файлов
+	if c.Bool("dry-run") {
+		for i, result := range results {
+			for _, file := range result.Files {
+				log.Println("would generate:", file.Name)
+			}
+
+			for _, name := range orphans[i] {
+				log.Println("would remove:", name)
+			}
+		}
+
+		return nil
+	}
+
+	// создаём каталоги для сохранения сгенерированных файлов, если их нет
+	var created []string // созданные каталоги, которые нужно удалить в случае ошибки
+	for _, result := range results {
+		if outFolder := result.Out; outFolder != "" && outFolder != "." {
+			if _, err := os.Stat(outFolder); os.IsNotExist(err) {
+				log.Println("creating output folder:", outFolder)
+				if err := os.MkdirAll(outFolder, 0o750); err != nil {
+					return fmt.Errorf("output folder %q: %w", outFolder, err)
+				}
+
+				created = append(created, outFolder)
+			}
+		}
+	}
+
+	// записываем в файлы только изменившийся сгенерированный код всех целей вместе
+	var files []generatedFile
+	changed := make([]int, len(results)) // количество изменившихся файлов по целям
+	for i, result := range results {
+		list, err := changedFiles(result.Files)
 		if err != nil {
-			return fmt.Errorf("match files %q: %w", arg, err)
+			return err
 		}
 
-		// добавляем в список файлов на обработку
-		for _, file := range matches {
-			files[file] = struct{}{}
+		files = append(files, list...)
+		changed[i] = len(list)
+	}
+
+	if err := writeFiles(files); err != nil {
+		for i := len(created) - 1; i >= 0; i-- {
+			_ = os.Remove(created[i]) // удаляем созданные каталоги, если они остались пустыми
 		}
+
+		return err
 	}
 
-	// проверяем, что есть файлы с описанием запросов
-	if len(files) == 0 {
-		return errors.New("the files with the description of the request were not found")
+	for _, file := range files {
+		log.Println("generated:", file.Name)
 	}
 
-	outFolder := c.Path("out") // каталог для записи файлов
-	// создаём каталог для сохранения сгенерированных файлов, если его нет
-	if outFolder != "" && outFolder != "." {
-		if _, err := os.Stat(outFolder); os.IsNotExist(err) {
-			log.Println("creating output folder:", outFolder)
-			if err := os.MkdirAll(outFolder, 0o750); err != nil {
-				return fmt.Errorf("output folder %q: %w", outFolder, err)
+	for i, result := range results {
+		if unchanged := len(result.Files) - changed[i]; unchanged > 0 {
+			log.Printf("unchanged: %d file(s) in %s", unchanged, outName(result.Out))
+		}
+
+		// сохраняем кеш генерации для следующего запуска
+		if result.Cache != nil {
+			if err := saveCache(result); err != nil {
+				return err
+			}
+		}
+
+		// удаляем файлы, описания которых больше не входят в исходные файлы
+		for _, name := range orphans[i] {
+			if err := os.Remove(name); err != nil {
+				return fmt.Errorf("remove orphaned file %q: %w", name, err)
 			}
+
+			log.Println("removed:", name)
 		}
 	}
 
-	name := c.String("name") // название пакета
-	if name == "" {
-		name = outFolder
+	// выводим общий итог по всем целям генерации
+	if len(results) > 1 {
+		for i, result := range results {
+			log.Printf("target %s: %d file(s), %d written, %d removed",
+				result.Target, len(result.Files), changed[i], len(orphans[i]))
+		}
 	}
 
-	// инициализируем генератор кода с заданным именем и списком импортируемых библиотек
-	generator := generator.New(name, c.StringSlice("import")...)
-	log.Println("package:  ", generator.Package)
+	log.Println("generation completed!")
 
-	// обрабатываем все файлы из нашего списка
-	for file := range files {
-		// разбираем описание запроса из файла
-		qs, err := config.Parse(file)
-		if err != nil {
-			return fmt.Errorf("parse: %w", err)
-		}
+	return nil
+}
 
-		// получаем сгенерированный код с описанием запросов
-		data, err := generator.Query(file, qs.Queries)
-		if err != nil {
-			log.Println("generating error:\n  ", err)
+// outName возвращает название каталога для вывода в сообщениях.
+func outName(out string) string {
+	if out == "" {
+		return "."
+	}
+
+	return out
+}
+
+// verify сравнивает сгенерированный в памяти код с существующими файлами и добавляет
+// в список проблем отсутствующие, устаревшие и лишние файлы.
+func verify(results []*buildResult, problems *config.Errors) error {
+	var errs config.Errors
+	var count int
+	orphans, err := orphansByTarget(results)
+	if err != nil {
+		return err
+	}
+
+	for i, result := range results {
+		for _, file := range result.Files {
+			data, err := os.ReadFile(file.Name)
+			switch {
+			case errors.Is(err, os.ErrNotExist):
+				errs.Add(config.Error{Code: config.CodeMissing, File: file.Name,
+					Message: "generated file is missing"})
+			case err != nil:
+				return err
+			case !bytes.Equal(data, file.Data):
+				errs.Add(config.Error{Code: config.CodeStale, File: file.Name,
+					Message: "generated file is out of date"})
+			}
 		}
 
-		// формируем новое имя файла и записываем в него получившийся код
-		destination := filepath.Join(outFolder, filepath.Base(file))
-		destination = destination[:len(destination)-len(filepath.Ext(destination))]
-		destination += ".sql.go"
-		if err = os.WriteFile(destination, data, 0o600); err != nil {
-			return fmt.Errorf("save file %q: %w", destination, err)
+		// файлы, созданные генератором, которые больше не генерируются, при записи удаляются
+		for _, name := range orphans[i] {
+			errs.Add(config.Error{Code: config.CodeExtra, File: name,
+				Message: "generated file is obsolete and will be removed"})
 		}
 
-		log.Println("generated:", destination)
+		count += len(result.Files)
 	}
 
-	// генерируем код инициализации библиотеки
-	data, err := generator.DB()
+	if len(errs) > 0 {
+		*problems = append(*problems, errs...)
+		return errProblems
+	}
+
+	log.Printf("verify completed: %d file(s) up to date", count)
+
+	return nil
+}
+
+// checkCmd выполняет все этапы генерации кода библиотеки без записи файлов и
+// возвращает ошибку, если найдена хотя бы одна проблема.
+func checkCmd(c *cli.Context, problems *config.Errors) error {
+	results, err := buildAll(c, problems, false)
 	if err != nil {
-		return fmt.Errorf("generate main: %w", err)
+		return err
 	}
 
-	// записываем код библиотеки в файл
-	destination := filepath.Join(outFolder, "db.go")
-	if err = os.WriteFile(destination, data, 0o600); err != nil {
-		return fmt.Errorf("save main %q: %w", destination, err)
+	if len(*problems) > 0 {
+		return errProblems
 	}
 
-	log.Println("generation completed!")
+	var count int
+	for _, result := range results {
+		count += len(result.Files)
+	}
+
+	log.Printf("check completed: %d file(s) ok", count)
 
 	return nil
 }
 
+// templatesCmd выводит исходный текст стандартных шаблонов генерации кода.
+func templatesCmd(c *cli.Context) error {
+	_, err := io.WriteString(c.App.Writer, generator.Templates())
+	return err
+}
+
 // helpString возвращает текст с переносом по строкам.
 func helpString(s string) string {
 	const maxWidth = 72
@@ -165,7 +364,32 @@ func helpString(s string) string {
 }
 
 const (
-	appDescription      = `The description of SQL queries and the parameters used in them is done using YAML files. Based on these descriptions, sqlgen generates a library to work with these queries.`
+	appDescription   = `The description of SQL queries and the parameters used in them is done using YAML files. Based on these descriptions, sqlgen generates a library to work with these queries.`
+	checkDescription = `This command runs the full generation pipeline without writing any files: it parses all query descriptions, checks types, imports and SQL queries, renders and formats the code. It exits with a non-zero code if any problem (error or warning) is found, so it can be used as a pre-commit check:
+	sqlgen check --out ./database`
+	vetDescription = `This command loads the database schema from the SQL files set by the "schema" flag into an in-memory SQLite database and prepares every query against it without executing. SQLite is embedded and written in pure Go, so no database server, network access or cgo is needed, but the schema and the queries must be valid for SQLite:
+	sqlgen vet --schema schema.sql
+
+The number of query parameters is compared with the "in" fields, and the number of result columns with the "out" fields. The column names and declared types are compared with the field names and types where possible, and a column that may be NULL is reported for a field type that can't hold it; these mismatches are reported as warnings. Problems are reported at the positions in the query files. The query files and the targets are selected the same way as for the "generate" command, and the command exits with a non-zero code if any problem is found.`
+	initDescription = `This command creates a starter query library in the given directory (the current directory by default): a query description file with commented examples of every query type, the project configuration file and the "doc.go" file with the package description and the "//go:generate sqlgen generate" directive. Existing files are never overwritten: if any of them exists, nothing is created:
+	sqlgen init ./database
+	cd ./database && go generate`
+	templatesDescription = `This command prints the default code templates, which can be used as a starting point for your own templates:
+	sqlgen templates > ./templates/sqlgen.tmpl
+
+The code is generated by executing the "generate queries" template for each query file and the "generate db" template for the main library file. Each of them can be overridden, as well as any of the templates they use ("func body", "struct fields", "package header" and others). A template file in the "templates" directory may contain only the overridden blocks: the rest are inherited from the default templates.
+
+The data passed to the templates is versioned. The "DataVersion" field contains the current version, which is increased on any incompatible change, so a template can check it. The "generate queries" template gets:
+	Generator   - the generator info: Name, Version and Package
+	DataVersion - the data version
+	Source      - the query file path relative to the output directory
+	Imports     - the list of imports with Name (alias) and Path, including "context" if the file has queries
+	Types       - the named types declared in the query file
+	Queries     - the list of queries from the query file
+
+The "generate db" template gets the same Generator and DataVersion, an empty Source, the Imports of the main file and the named Types from the project configuration.
+
+In addition to the standard functions, the templates can use: name, funcName, fieldName, param, escape, scanValue (how a named type value is scanned and stored), enumName (the constant name of an enum value) and testValue (a test value of a type).`
 	generateDescription = `This command generates the golang library with SQL queries.
 	
 By default, the generated files are written to the current directory. Using the flag "out" you can explicitly specify a directory for generating files:
@@ -180,6 +404,57 @@ Initially, the generator automatically supports standard data types defined in t
 If you want to use third-party libraries in the description of data types, then this must be explicitly specified by setting them using the "import" flag:
 	sqlgen generate --import github.com/gofrs/uuid
 
-The library prefix is determined by the last element in the package path and does not contain any other code to determine the actual name. Therefore, if the prefix you are using is different, then it is necessary explicitly specify a colon before the package name:
-	sqlgen generate --import uuid:github.com/vgarvardt/pgx-google-uuid/v5`
+The library prefix is derived from the import path only, the same way goimports does it: major version suffixes (/v5), gopkg.in conventions (yaml.v3) and "go-" or "go." repository prefixes (go.uuid) are taken into account, so the generated code does not depend on the module cache. If the prefix differs from the last path element, the package is imported under an alias; if the actual package name doesn't match, type checking reports an error. If you want to use a different prefix, then specify it explicitly with a colon before the package path; the package is imported under this alias:
+	sqlgen generate --import uuid:github.com/vgarvardt/pgx-google-uuid/v5
+
+The "dialect" flag sets the SQL dialect of the queries (postgres, mysql or sqlite). Only the parameters the dialect binds are counted: "$1" for postgres, where "?" is the jsonb operator, "?" for mysql, and also "?1" and named parameters for sqlite. Without a dialect all of them are counted, and a mismatch with the "in" fields is reported as a warning rather than an error:
+	sqlgen generate --out ./database --dialect postgres
+
+Generation is transactional: all files are rendered in memory first, and if any of them fails, nothing is written and the command exits with a non-zero code. Files are written to temporary files and then atomically renamed; the permissions of existing files are preserved, and files with unchanged content are not rewritten.
+
+The "verify" flag regenerates everything in memory and compares it byte-for-byte with the existing files without writing anything. Missing, stale and extra generated files are reported, and the command exits with a non-zero code, so CI can detect that the code was not regenerated:
+	sqlgen generate --out ./database --verify
+
+Files previously generated by sqlgen (recognized by the "Code generated by sqlgen" header) that are not generated now are removed if their source file referenced in the "source:" header line no longer exists, is not among the sources of a target from the project configuration, or now generates other files (for example, test files after the "tests" flag is turned off). Files whose source exists but is not passed on the command line are kept. The "verify" flag reports files to be removed as obsolete. Use the "dry-run" flag to list files to be written and removed without changing anything:
+	sqlgen generate --out ./database --dry-run
+
+Generated files whose content has not changed are not rewritten, so their modification time is preserved. The hashes of the query files and of the generated code are stored in the ".sqlgen.cache" file in the output directory, together with the generator version, a hash of its build and default templates, and the options, so an upgraded or rebuilt generator regenerates all files. Query files that have not changed since the previous run are not parsed and generated again. Use the "no-cache" flag to regenerate all files:
+	sqlgen generate --out ./database --no-cache
+
+The "watch" flag keeps the command running: the query files matching the arguments and the project configuration file are polled for changes with the given "interval", and the code is regenerated after the changes settle down. Problems are printed without stopping the command. Press Ctrl+C to exit:
+	sqlgen generate --out ./database --watch
+
+Query files are parsed, checked and generated in parallel. The "jobs" flag limits the number of files processed at the same time; the output and the order of reported problems don't depend on it:
+	sqlgen generate --out ./database --jobs 4
+
+The code is generated from the embedded templates. Use the "templates" flag to set a directory with *.tmpl files: the templates defined there with {{define "name"}} override the default templates with the same name, and the rest are inherited. See the "templates" command for details:
+	sqlgen generate --templates ./templates
+
+Outgoing parameters of "one" and "many" queries can be omitted or set to "auto" when the database schema is known. Use the "schema" flag to set SQL files with the schema (DDL): they are loaded into an embedded in-memory SQLite database, and the fields are inferred from the result columns of the query: the names from the column names or aliases, the types from the declared column types. Columns that may be NULL, including columns of tables joined with an outer join, get nullable types. Explicitly described outgoing parameters are used as is:
+	sqlgen generate --out ./database --schema schema.sql
+
+Named types declared in the "types" section of a query file (or of the project configuration for all files) are generated in the package and used in field descriptions without a package prefix. A type is set by its underlying type, and "scan: true" adds Scan and Value methods for string, integer, float and boolean types:
+	types:
+	  UserID: string
+	  Score: {type: int, scan: true}
+
+An enum is a named string or integer type with the list of allowed "values" by name (the value of a string enum defaults to its name). It gets constants, the Valid and String methods, and the Scan and Value methods that reject NULL and unknown values with an error:
+	types:
+	  Status:
+	    type: string
+	    values: {new: , in progress: in_progress, done: }
+
+The default mapping of SQL types to Go types can be extended or changed in the "sql_types" section of the project configuration. Each entry sets the SQL type ("sql"), the Go type ("go") and optionally the type for NULL values ("nullable", a pointer by default) and the dialect it applies to ("dialect"). Go types from other packages are set with the full import path, e.g. "github.com/google/uuid.UUID", and the package is imported automatically. Entries for the target dialect take precedence over entries without a dialect.
+
+The "tests" flag also generates a "*_sql_test.go" file with a test for every query and the "db_test.go" file with a fake database driver, so the tests have no external dependencies. Each test runs the generated method and checks the SQL text, the order of arguments and scanning of the declared columns:
+	sqlgen generate --out ./database --tests
+
+Problems found in the query descriptions are printed as text. Use the "diagnostics" flag to get them in a machine-readable format (json or sarif) on the standard output:
+	sqlgen generate --diagnostics=sarif > sqlgen.sarif
+
+Project settings are read from the "sqlgen.yaml" file in the current directory, if it exists. Use the "config" flag to set another file:
+	sqlgen generate --config ./db/sqlgen.yaml
+
+The project configuration can describe several targets, each with its own query files ("sources"), output directory ("out"), package name ("package"), imports, SQL dialect ("dialect": postgres, mysql or sqlite), initialisms and templates. Relative paths are resolved against the configuration file directory. All targets are checked and generated in one run, and nothing is written if any of them fails. The query files and the "out", "name", "import", "templates" and "dialect" flags can't be used with targets; use the "target" flag to process only some of them:
+	sqlgen generate --target users --target billing`
 )
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mdigger/sqlgen/generator"
+)
+
+// ownedFile описывает файл, ранее сгенерированный sqlgen.
+type ownedFile struct {
+	Name   string // путь к файлу
+	Source string // исходный файл с описанием запросов из заголовка
+}
+
+// ownedFiles возвращает список файлов *.sql.go, *_sql_test.go и файла тестовой базы данных
+// в каталоге, которые были созданы генератором. Принадлежность определяется по первой
+// строке заголовка файла.
+func ownedFiles(dir string) ([]ownedFile, error) {
+	if dir == "" {
+		dir = "."
+	}
+
+	var matches []string
+	for _, pattern := range []string{"*.sql.go", "*_sql_test.go", generator.DBTestFile} {
+		list, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+
+		matches = append(matches, list...)
+	}
+
+	sort.Strings(matches)
+
+	var list []ownedFile
+	for _, name := range matches {
+		source, ok, err := readHeader(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			list = append(list, ownedFile{Name: name, Source: source})
+		}
+	}
+
+	return list, nil
+}
+
+// readHeader читает заголовок файла и возвращает путь к исходному файлу, указанный в нём.
+// Возвращает false, если файл создан не генератором.
+func readHeader(name string) (source string, owned bool, err error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return "", false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() || scanner.Text() != generator.Header {
+		return "", false, scanner.Err()
+	}
+
+	// заголовок заканчивается на первой строке, которая не является комментарием
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "//") {
+			break
+		}
+
+		if value, ok := strings.CutPrefix(line, "// source: "); ok {
+			source = value
+		}
+	}
+
+	return source, true, scanner.Err()
+}
+
+// orphanFiles возвращает список файлов, ранее созданных генератором в каталоге dir, которые
+// не были сгенерированы сейчас (generated — пути к сгенерированным файлам) и больше не
+// соответствуют исходным файлам:
+//   - исходный файл из заголовка больше не существует;
+//   - по исходному файлу сейчас сгенерированы другие файлы (sources — абсолютные пути
+//     обработанных исходных файлов), например, отключена генерация тестов;
+//   - исходный файл не входит в список исходных файлов цели из настроек проекта inputs
+//     (абсолютные пути); если цель задана аргументами команды, то inputs равен nil
+//     и файлы, исходные файлы которых существуют, но не указаны, сохраняются.
+//
+// Из файлов без указания исходного файла в заголовке потерянным считается только не
+// сгенерированный сейчас файл тестовой базы данных.
+func orphanFiles(dir string, generated, sources, inputs map[string]bool) ([]string, error) {
+	owned, err := ownedFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []string
+	for _, file := range owned {
+		if generated[filepath.Clean(file.Name)] {
+			continue
+		}
+
+		if file.Source == "" {
+			if filepath.Base(file.Name) == generator.DBTestFile {
+				orphans = append(orphans, file.Name)
+			}
+
+			continue
+		}
+
+		source, exists := sourceFile(dir, file.Source)
+		if !exists || sources[source] || (inputs != nil && !inputs[source]) {
+			orphans = append(orphans, file.Name)
+		}
+	}
+
+	return orphans, nil
+}
+
+// sourceFile возвращает абсолютный путь к исходному файлу из заголовка и признак его
+// существования. Путь к исходному файлу указывается относительно каталога со сгенерированными
+// файлами; для файлов, созданных предыдущими версиями генератора, путь проверяется так же
+// относительно текущего каталога.
+func sourceFile(dir, source string) (string, bool) {
+	names := []string{filepath.Join(dir, filepath.FromSlash(source)), filepath.FromSlash(source)}
+	for _, name := range names {
+		if _, err := os.Stat(name); err == nil {
+			return absPath(name), true
+		}
+	}
+
+	return absPath(names[0]), false
+}
+
+// absPath возвращает абсолютный путь к файлу или исходный путь, если его не удалось определить.
+func absPath(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+
+	return filepath.Clean(name)
+}
+
+// orphansByTarget возвращает для каждой цели генерации results список потерянных файлов
+// в её каталоге (см. orphanFiles). Если несколько целей записывают файлы в один каталог,
+// то файлы из него возвращаются только для первой из них, а файлы других целей в том же
+// каталоге потерянными не считаются.
+func orphansByTarget(results []*buildResult) ([][]string, error) {
+	generated := make(map[string]bool)
+	sources := make(map[string]bool)
+	for _, result := range results {
+		for _, file := range result.Files {
+			generated[filepath.Clean(file.Name)] = true
+			if file.Source != "" {
+				sources[absPath(file.Source)] = true
+			}
+		}
+	}
+
+	orphans := make([][]string, len(results))
+	dirs := make(map[string]bool, len(results))
+	for i, result := range results {
+		dir := filepath.Clean(outName(result.Out))
+		if dirs[dir] {
+			continue
+		}
+
+		dirs[dir] = true
+
+		var err error
+		if orphans[i], err = orphanFiles(result.Out, generated, sources, result.Inputs); err != nil {
+			return nil, err
+		}
+	}
+
+	return orphans, nil
+}
@@ -0,0 +1,150 @@
+package schema
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/mdigger/sqlgen/config"
+)
+
+// Infer определяет по структуре базы данных исходящие параметры запросов, для которых
+// они не описаны явно ("out: auto" или описание не задано). Названия полей соответствуют
+// названиям колонок ответа (с учётом псевдонимов), а типы определяются по объявленным типам
+// колонок таблиц с помощью соответствия types; если оно не задано, то используется
+// [DefaultTypes]. Явно описанные исходящие параметры не изменяются.
+//
+// Типы выражений не известны, поэтому для них исходящие параметры нужно описывать явно.
+func (s *Schema) Infer(qs *config.Queries, types TypeMap) config.Errors {
+	if types == nil {
+		types = DefaultTypes()
+	}
+
+	var errs config.Errors
+	anchors := make(map[string][]config.Field) // определённые поля по названию ссылки
+	for i := range qs.Queries {
+		q := &qs.Queries[i]
+		if !q.Out.Auto || len(q.Out.Fields) > 0 {
+			continue
+		}
+
+		qerrs := s.infer(q, types)
+		errs = append(errs, qerrs...)
+		if len(qerrs) > 0 {
+			continue
+		}
+
+		// поля запросов со ссылкой на одно описание должны совпадать,
+		// так как для них используется одна структура
+		if q.Out.Anchor != "" {
+			anchors[q.Out.Anchor] = q.Out.Fields
+		}
+
+		if fields, ok := anchors[q.Out.Alias]; ok && q.Out.Alias != "" && !sameFields(fields, q.Out.Fields) {
+			errs.Add(q.SQLErrorf(config.CodeColumnType, nil,
+				"inferred columns differ from the columns of %q", q.Out.Alias))
+		}
+	}
+
+	return errs
+}
+
+// infer определяет исходящие параметры запроса по структуре базы данных.
+func (s *Schema) infer(q *config.Query, types TypeMap) config.Errors {
+	var errs config.Errors
+
+	st, err := s.Describe(q.SQL.Query)
+	if err != nil {
+		errs.Add(q.SQLErrorf(config.CodeSchema, err, "invalid query"))
+		return errs
+	}
+
+	if len(st.Columns) == 0 {
+		errs.Add(q.SQLErrorf(config.CodeColumnCount, nil, "query returns no columns"))
+		return errs
+	}
+
+	outer := outerJoin(q.SQL.Query)
+	for i, col := range st.Columns {
+		if !isIdent(col.Name) {
+			errs.Add(q.SQLErrorf(config.CodeColumnName, nil,
+				"column #%d %q has no name: set an alias or describe out explicitly", i+1, col.Name))
+			continue
+		}
+
+		typ, ok := types.GoType(col.Type, outer.column(col))
+		if !ok {
+			errs.Add(q.SQLErrorf(config.CodeColumnType, nil,
+				"can't infer column %q type: describe out explicitly", col.Name))
+			continue
+		}
+
+		errs.Add(q.Out.Add(col.Name, typ))
+	}
+
+	if len(errs) > 0 {
+		q.Out.Fields = nil // не используем частично определённые поля
+	}
+
+	return errs
+}
+
+// sameFields возвращает true, если названия и типы полей совпадают.
+func sameFields(a, b []config.Field) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Name != b[i].Name || a[i].Type != b[i].Type {
+			return false
+		}
+	}
+
+	return true
+}
+
+var (
+	// fromTable находит первую таблицу в запросе.
+	fromTable = regexp.MustCompile("(?i)\\bfrom\\s+[\"`\\[]?(?:\\w+[\"`\\]]?\\.[\"`\\[]?)?(\\w+)")
+	// outerJoins находит внешние соединения таблиц в запросе.
+	outerJoins = regexp.MustCompile(`(?i)\b(left|right|full)(?:\s+outer)?\s+join\b`)
+)
+
+// joins описывает внешние соединения таблиц в запросе.
+type joins struct {
+	left  bool   // есть левое внешнее соединение
+	all   bool   // есть правое или полное внешнее соединение
+	table string // первая таблица в запросе
+}
+
+// outerJoin возвращает описание внешних соединений таблиц в запросе. Поддерживаются только
+// простые запросы: для левого соединения все таблицы, кроме первой, считаются
+// присоединёнными, а для правого и полного — все таблицы.
+func outerJoin(query string) joins {
+	var j joins
+	for _, m := range outerJoins.FindAllStringSubmatch(query, -1) {
+		if strings.EqualFold(m[1], "left") {
+			j.left = true
+		} else {
+			j.all = true
+		}
+	}
+
+	if m := fromTable.FindStringSubmatch(query); m != nil {
+		j.table = m[1]
+	}
+
+	return j
+}
+
+// nullable возвращает true, если колонки таблицы могут содержать NULL из-за внешнего соединения.
+func (j joins) nullable(table string) bool {
+	return j.all || (j.left && !strings.EqualFold(table, j.table))
+}
+
+// column возвращает true, если колонка ответа может содержать NULL: колонка таблицы объявлена
+// без NOT NULL или таблица присоединена внешним соединением. Выражения считаются колонками,
+// которые могут содержать NULL.
+func (j joins) column(col Column) bool {
+	return !col.NotNull || j.nullable(col.Table)
+}
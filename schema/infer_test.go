@@ -0,0 +1,70 @@
+package schema
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestInfer(t *testing.T) {
+	s := newSchema(t, testDDL+`
+create table codes (code text primary key, title text not null);
+`)
+
+	for _, tt := range []struct {
+		name   string
+		sql    string
+		fields []string // название и тип поля
+		errs   []string
+	}{{
+		name:   "rowid alias",
+		sql:    "select id, name from users",
+		fields: []string{"id int64", "name string"},
+	}, {
+		name:   "nullable text key",
+		sql:    "select code, title from codes",
+		fields: []string{"code sql.NullString", "title string"},
+	}, {
+		name:   "alias",
+		sql:    "select id as user_id from users",
+		fields: []string{"user_id int64"},
+	}, {
+		name:   "inner join",
+		sql:    "select u.name, o.id, o.total from users u join orders o on o.user_id = u.id",
+		fields: []string{"name string", "id int64", "total float64"},
+	}, {
+		name:   "left join",
+		sql:    "select u.name, o.id, o.total from users u left join orders o on o.user_id = u.id",
+		fields: []string{"name string", "id sql.NullInt64", "total sql.NullFloat64"},
+	}, {
+		name:   "left outer join",
+		sql:    "select u.name, o.total from users u left outer join orders o on o.user_id = u.id",
+		fields: []string{"name string", "total sql.NullFloat64"},
+	}, {
+		name:   "full join",
+		sql:    "select u.name, o.total from users u full join orders o on o.user_id = u.id",
+		fields: []string{"name sql.NullString", "total sql.NullFloat64"},
+	}, {
+		name: "expression without alias",
+		sql:  "select count(*) from users",
+		errs: []string{`SG020 column #1 "count(*)" has no name: set an alias or describe out explicitly`},
+	}, {
+		name: "expression type",
+		sql:  "select count(*) as total from users",
+		errs: []string{`SG021 can't infer column "total" type: describe out explicitly`},
+	}} {
+		qs := parseQueries(t, "query:\n  type: many\n  sql: "+tt.sql+"\n")
+		if got := problems(s.Infer(qs, nil)); !slices.Equal(got, tt.errs) {
+			t.Errorf("%s: errors = %q, want %q", tt.name, got, tt.errs)
+			continue
+		}
+
+		var fields []string
+		for _, f := range qs.Queries[0].Out.Fields {
+			fields = append(fields, f.Name+" "+f.Type)
+		}
+
+		if !slices.Equal(fields, tt.fields) {
+			t.Errorf("%s: fields = %q, want %q", tt.name, fields, tt.fields)
+		}
+	}
+}
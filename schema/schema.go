@@ -0,0 +1,354 @@
+// Package schema загружает описание структуры базы данных (DDL) во встроенную базу данных
+// SQLite в памяти и описывает SQL запросы по этой структуре: количество параметров
+// и колонки ответа с их типами.
+//
+// Используется реализация SQLite на чистом golang, поэтому проверка работает без
+// внешней базы данных, сетевого доступа и cgo. Описание структуры базы данных и тексты
+// запросов должны разбираться SQLite.
+package schema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+	"unsafe"
+
+	"github.com/mdigger/sqlgen/config"
+	"modernc.org/libc"
+	"modernc.org/libc/sys/types"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Schema описывает загруженную в память структуру базы данных.
+// Может безопасно использоваться из нескольких горутин.
+type Schema struct {
+	mu  sync.Mutex
+	tls *libc.TLS
+	db  uintptr // дескриптор базы данных sqlite3*
+}
+
+// Column описывает колонку ответа на запрос.
+type Column struct {
+	Name    string // название колонки в ответе
+	Type    string // объявленный тип колонки таблицы; пусто для выражений
+	Table   string // название таблицы с колонкой; пусто для выражений
+	Origin  string // название колонки в таблице; пусто для выражений
+	NotNull bool   // колонка таблицы не может содержать NULL
+}
+
+// Statement описывает подготовленный SQL запрос.
+type Statement struct {
+	Params  int      // количество параметров
+	Columns []Column // колонки ответа
+}
+
+// ptrSize задаёт размер указателя в памяти SQLite.
+const ptrSize = int(unsafe.Sizeof(uintptr(0)))
+
+// New возвращает пустую базу данных в памяти.
+func New() (*Schema, error) {
+	s := &Schema{tls: libc.NewTLS()}
+
+	name, err := libc.CString(":memory:")
+	if err != nil {
+		s.tls.Close()
+		return nil, err
+	}
+	defer s.free(name)
+
+	pdb, err := s.malloc(ptrSize)
+	if err != nil {
+		s.tls.Close()
+		return nil, err
+	}
+	defer s.free(pdb)
+
+	rc := sqlite3.Xsqlite3_open_v2(s.tls, name, pdb,
+		sqlite3.SQLITE_OPEN_READWRITE|sqlite3.SQLITE_OPEN_CREATE|sqlite3.SQLITE_OPEN_MEMORY, 0)
+	s.db = libc.AtomicLoadPUintptr(pdb)
+	if rc != sqlite3.SQLITE_OK {
+		err := s.error(rc)
+		s.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Load возвращает базу данных в памяти со структурой, описанной в файлах с DDL.
+// Файлы выполняются в заданном порядке.
+func Load(filenames ...string) (*Schema, error) {
+	s, err := New()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, filename := range filenames {
+		data, err := os.ReadFile(filename)
+		if err == nil {
+			err = s.Exec(filename, data)
+		}
+
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Close освобождает ресурсы базы данных.
+func (s *Schema) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tls == nil {
+		return nil
+	}
+
+	var err error
+	if s.db != 0 {
+		if rc := sqlite3.Xsqlite3_close_v2(s.tls, s.db); rc != sqlite3.SQLITE_OK {
+			err = s.error(rc)
+		}
+
+		s.db = 0
+	}
+
+	s.tls.Close()
+	s.tls = nil
+
+	return err
+}
+
+// Exec выполняет все SQL команды из описания структуры базы данных ddl.
+// Ошибка возвращается в виде [config.Error] с позицией команды в файле filename.
+func (s *Schema) Exec(filename string, ddl []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	text, err := libc.CString(string(ddl))
+	if err != nil {
+		return err
+	}
+	defer s.free(text)
+
+	// выполняем команды по одной, чтобы знать позицию команды с ошибкой
+	for offset := 0; offset < len(ddl); {
+		stmt, next, err := s.prepare(text + uintptr(offset))
+		if err != nil {
+			errOffset := int(sqlite3.Xsqlite3_error_offset(s.tls, s.db))
+			return ddlError(filename, ddl, offset+max(errOffset, 0), err)
+		}
+
+		if stmt != 0 {
+			rc := sqlite3.Xsqlite3_step(s.tls, stmt)
+			if rc != sqlite3.SQLITE_DONE && rc != sqlite3.SQLITE_ROW {
+				err = s.error(rc)
+			}
+
+			sqlite3.Xsqlite3_finalize(s.tls, stmt)
+			if err != nil {
+				return ddlError(filename, ddl, offset, err)
+			}
+		}
+
+		if next <= text+uintptr(offset) {
+			break // защита от зацикливания
+		}
+
+		offset = int(next - text)
+	}
+
+	return nil
+}
+
+// Describe подготавливает SQL запрос без выполнения и возвращает его описание.
+// Если запрос содержит несколько команд, то описывается только первая из них.
+func (s *Schema) Describe(query string) (*Statement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	text, err := libc.CString(query)
+	if err != nil {
+		return nil, err
+	}
+	defer s.free(text)
+
+	stmt, _, err := s.prepare(text)
+	if err != nil {
+		return nil, err
+	}
+
+	if stmt == 0 {
+		return nil, errors.New("empty query")
+	}
+	defer sqlite3.Xsqlite3_finalize(s.tls, stmt)
+
+	st := &Statement{
+		Params:  int(sqlite3.Xsqlite3_bind_parameter_count(s.tls, stmt)),
+		Columns: make([]Column, sqlite3.Xsqlite3_column_count(s.tls, stmt)),
+	}
+
+	for i := range st.Columns {
+		col := &st.Columns[i]
+		n := int32(i)
+		col.Name = libc.GoString(sqlite3.Xsqlite3_column_name(s.tls, stmt, n))
+		col.Type = libc.GoString(sqlite3.Xsqlite3_column_decltype(s.tls, stmt, n))
+		col.Table = libc.GoString(sqlite3.Xsqlite3_column_table_name(s.tls, stmt, n))
+		col.Origin = libc.GoString(sqlite3.Xsqlite3_column_origin_name(s.tls, stmt, n))
+		if col.Table != "" && col.Origin != "" {
+			db := libc.GoString(sqlite3.Xsqlite3_column_database_name(s.tls, stmt, n))
+			col.NotNull = s.notNull(db, col.Table, col.Origin)
+		}
+	}
+
+	return st, nil
+}
+
+// notNull возвращает true, если колонка таблицы не может содержать NULL: объявлена как
+// NOT NULL или является первичным ключом, который SQLite не позволяет задать как NULL.
+// SQLite допускает NULL в колонках первичного ключа, поэтому первичный ключ учитывается
+// только для синонима rowid (INTEGER PRIMARY KEY) и для таблиц WITHOUT ROWID.
+func (s *Schema) notNull(db, table, column string) bool {
+	var strs [3]uintptr
+	defer func() {
+		for _, p := range strs {
+			s.free(p)
+		}
+	}()
+
+	for i, str := range []string{db, table, column} {
+		p, err := libc.CString(str)
+		if err != nil {
+			return false
+		}
+
+		strs[i] = p
+	}
+
+	// значения флагов NOT NULL и PRIMARY KEY
+	flags, err := s.malloc(8)
+	if err != nil {
+		return false
+	}
+	defer s.free(flags)
+
+	if rc := sqlite3.Xsqlite3_table_column_metadata(s.tls, s.db, strs[0], strs[1], strs[2],
+		0, 0, flags, flags+4, 0); rc != sqlite3.SQLITE_OK {
+		return false
+	}
+
+	switch {
+	case libc.AtomicLoadPInt32(flags) != 0:
+		return true
+	case libc.AtomicLoadPInt32(flags+4) != 0:
+		return s.rowidKey(db, table, column)
+	default:
+		return false
+	}
+}
+
+// rowidKey возвращает true, если колонка первичного ключа таблицы является синонимом rowid
+// или таблица объявлена как WITHOUT ROWID и не содержит rowid.
+func (s *Schema) rowidKey(db, table, column string) bool {
+	text, err := libc.CString(fmt.Sprintf("SELECT rowid FROM %s.%s", quoteName(db), quoteName(table)))
+	if err != nil {
+		return false
+	}
+	defer s.free(text)
+
+	stmt, _, err := s.prepare(text)
+	if err != nil {
+		return true // в таблице WITHOUT ROWID нет колонки rowid
+	}
+
+	if stmt == 0 {
+		return false
+	}
+	defer sqlite3.Xsqlite3_finalize(s.tls, stmt)
+
+	// для синонима rowid возвращается название объявленной колонки первичного ключа
+	origin := libc.GoString(sqlite3.Xsqlite3_column_origin_name(s.tls, stmt, 0))
+	return strings.EqualFold(origin, column)
+}
+
+// quoteName возвращает название в кавычках для использования в SQL.
+func quoteName(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// prepare подготавливает первую SQL команду из текста text и возвращает её дескриптор
+// и указатель на оставшийся текст. Для пустой команды возвращается нулевой дескриптор.
+func (s *Schema) prepare(text uintptr) (stmt, tail uintptr, err error) {
+	ptrs, err := s.malloc(2 * ptrSize)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer s.free(ptrs)
+
+	pstmt, ptail := ptrs, ptrs+uintptr(ptrSize)
+	if rc := sqlite3.Xsqlite3_prepare_v2(s.tls, s.db, text, -1, pstmt, ptail); rc != sqlite3.SQLITE_OK {
+		return 0, 0, s.error(rc)
+	}
+
+	return libc.AtomicLoadPUintptr(pstmt), libc.AtomicLoadPUintptr(ptail), nil
+}
+
+// error возвращает описание последней ошибки SQLite.
+func (s *Schema) error(rc int32) error {
+	if s.db != 0 {
+		if msg := libc.GoString(sqlite3.Xsqlite3_errmsg(s.tls, s.db)); msg != "" {
+			return errors.New(msg)
+		}
+	}
+
+	return errors.New(libc.GoString(sqlite3.Xsqlite3_errstr(s.tls, rc)))
+}
+
+// malloc выделяет память для SQLite.
+func (s *Schema) malloc(n int) (uintptr, error) {
+	if p := libc.Xmalloc(s.tls, types.Size_t(n)); p != 0 {
+		return p, nil
+	}
+
+	return 0, fmt.Errorf("sqlite: cannot allocate %d bytes of memory", n)
+}
+
+// free освобождает выделенную для SQLite память.
+func (s *Schema) free(p uintptr) {
+	if p != 0 {
+		libc.Xfree(s.tls, p)
+	}
+}
+
+// ddlError возвращает описание ошибки выполнения описания структуры базы данных с позицией,
+// соответствующей смещению offset в байтах.
+func ddlError(filename string, ddl []byte, offset int, err error) error {
+	offset = min(offset, len(ddl))
+
+	// пропускаем пробелы перед командой
+	for offset < len(ddl) && strings.ContainsRune(" \t\r\n", rune(ddl[offset])) {
+		offset++
+	}
+
+	start := bytes.LastIndexByte(ddl[:offset], '\n') + 1
+	end := bytes.IndexByte(ddl[start:], '\n')
+	if end < 0 {
+		end = len(ddl) - start
+	}
+
+	return config.Error{
+		Code:    config.CodeSchema,
+		Message: err.Error(),
+		File:    filename,
+		Line:    bytes.Count(ddl[:offset], []byte{'\n'}) + 1,
+		Column:  utf8.RuneCount(ddl[start:offset]) + 1,
+		Snippet: strings.TrimRight(string(ddl[start:start+end]), "\r"),
+	}
+}
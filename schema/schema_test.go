@@ -0,0 +1,65 @@
+package schema
+
+import "testing"
+
+// newSchema возвращает базу данных в памяти со структурой ddl.
+func newSchema(t *testing.T, ddl string) *Schema {
+	t.Helper()
+
+	s, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { s.Close() })
+
+	if err := s.Exec("schema.sql", []byte(ddl)); err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestDescribeNotNull(t *testing.T) {
+	s := newSchema(t, `
+create table rowid_alias (id integer primary key, name text);
+create table int_key (id int primary key);
+create table desc_key (id integer primary key desc);
+create table text_key (code text primary key, title text not null);
+create table composite_key (a integer, b integer, primary key (a, b));
+create table without_rowid (code text primary key, value integer) without rowid;
+`)
+
+	for _, tt := range []struct {
+		query   string
+		notNull []bool
+	}{
+		// INTEGER PRIMARY KEY является синонимом rowid и не может содержать NULL
+		{"select id, name from rowid_alias", []bool{true, false}},
+		{"select rowid from rowid_alias", []bool{true}},
+		// остальные первичные ключи в таблицах с rowid могут содержать NULL
+		{"select id from int_key", []bool{false}},
+		{"select id from desc_key", []bool{false}},
+		{"select code, title from text_key", []bool{false, true}},
+		{"select a, b from composite_key", []bool{false, false}},
+		// в таблицах WITHOUT ROWID первичный ключ не может содержать NULL
+		{"select code, value from without_rowid", []bool{true, false}},
+		// выражения могут содержать NULL
+		{"select id + 1 as next, 'x' as const from rowid_alias", []bool{false, false}},
+	} {
+		st, err := s.Describe(tt.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.query, err)
+		}
+
+		if len(st.Columns) != len(tt.notNull) {
+			t.Fatalf("%s: %d columns, want %d", tt.query, len(st.Columns), len(tt.notNull))
+		}
+
+		for i, col := range st.Columns {
+			if col.NotNull != tt.notNull[i] {
+				t.Errorf("%s: column %q not null = %v, want %v", tt.query, col.Name, col.NotNull, tt.notNull[i])
+			}
+		}
+	}
+}
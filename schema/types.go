@@ -0,0 +1,104 @@
+package schema
+
+import (
+	"strings"
+)
+
+// GoType описывает типы golang, которые используются для колонок с заданным типом SQL.
+type GoType struct {
+	Type     string // тип для колонки, которая не может содержать NULL
+	Nullable string // тип для колонки, которая может содержать NULL (по умолчанию — указатель на Type)
+}
+
+// TypeMap задаёт соответствие типов колонок SQL типам golang. Названия типов SQL задаются
+// в нижнем регистре без размера и точности: "varchar", "numeric", "double precision".
+//
+// Если для объявленного типа колонки нет соответствия, то используется соответствие для
+// типа колонки SQLite, определённого по объявленному типу: "integer", "text", "real",
+// "numeric" или "blob".
+type TypeMap map[string]GoType
+
+// DefaultTypes возвращает соответствие типов колонок SQL типам golang по умолчанию.
+// Используются только типы из стандартных пакетов, поддерживаемых генератором.
+func DefaultTypes() TypeMap {
+	return TypeMap{
+		"integer":                  {Type: "int64", Nullable: "sql.NullInt64"},
+		"text":                     {Type: "string", Nullable: "sql.NullString"},
+		"real":                     {Type: "float64", Nullable: "sql.NullFloat64"},
+		"numeric":                  {Type: "string", Nullable: "sql.NullString"},
+		"blob":                     {Type: "[]byte", Nullable: "[]byte"},
+		"bool":                     {Type: "bool", Nullable: "sql.NullBool"},
+		"boolean":                  {Type: "bool", Nullable: "sql.NullBool"},
+		"date":                     {Type: "time.Time", Nullable: "sql.NullTime"},
+		"datetime":                 {Type: "time.Time", Nullable: "sql.NullTime"},
+		"timestamp":                {Type: "time.Time", Nullable: "sql.NullTime"},
+		"timestamptz":              {Type: "time.Time", Nullable: "sql.NullTime"},
+		"timestamp with time zone": {Type: "time.Time", Nullable: "sql.NullTime"},
+		"json":                     {Type: "json.RawMessage", Nullable: "json.RawMessage"},
+		"jsonb":                    {Type: "json.RawMessage", Nullable: "json.RawMessage"},
+	}
+}
+
+// affinityNames содержит названия типов колонок SQLite.
+var affinityNames = [...]string{
+	affinityBlob:    "blob",
+	affinityText:    "text",
+	affinityNumeric: "numeric",
+	affinityInteger: "integer",
+	affinityReal:    "real",
+}
+
+// GoType возвращает тип golang для колонки с объявленным типом decl. Если колонка может
+// содержать NULL, то возвращается тип для таких колонок. Для колонок без объявленного типа
+// (выражений) тип не определяется и возвращается false.
+func (m TypeMap) GoType(decl string, nullable bool) (string, bool) {
+	t, ok := m.Lookup(decl)
+	switch {
+	case !ok || t.Type == "":
+		return "", false
+	case nullable:
+		return t.NullableType(), true
+	default:
+		return t.Type, true
+	}
+}
+
+// Lookup возвращает соответствие для объявленного типа колонки decl. Если соответствие для
+// названия типа не задано, то возвращается соответствие для типа колонки SQLite.
+func (m TypeMap) Lookup(decl string) (GoType, bool) {
+	name := typeName(decl)
+	if name == "" {
+		return GoType{}, false
+	}
+
+	t, ok := m[name]
+	if !ok {
+		t, ok = m[affinityNames[columnAffinity(decl)]]
+	}
+
+	return t, ok
+}
+
+// Set задаёт соответствие для типа SQL с названием name.
+func (m TypeMap) Set(name string, t GoType) {
+	m[typeName(name)] = t
+}
+
+// NullableType возвращает тип golang для колонки, которая может содержать NULL.
+func (t GoType) NullableType() string {
+	if t.Nullable != "" {
+		return t.Nullable
+	}
+
+	return "*" + t.Type
+}
+
+// typeName возвращает название объявленного типа колонки в нижнем регистре без размера
+// и точности и с одним пробелом между словами.
+func typeName(decl string) string {
+	if i := strings.IndexByte(decl, '('); i >= 0 {
+		decl = decl[:i]
+	}
+
+	return strings.Join(strings.Fields(strings.ToLower(decl)), " ")
+}
@@ -0,0 +1,226 @@
+package schema
+
+import (
+	"go/ast"
+	"strings"
+
+	"github.com/mdigger/sqlgen/config"
+)
+
+// affinity описывает тип колонки SQLite, определяемый по объявленному типу.
+type affinity uint8
+
+// Типы колонок SQLite.
+const (
+	affinityBlob affinity = iota
+	affinityText
+	affinityNumeric
+	affinityInteger
+	affinityReal
+)
+
+// columnAffinity возвращает тип колонки по её объявленному типу по правилам SQLite.
+func columnAffinity(decl string) affinity {
+	decl = strings.ToUpper(decl)
+	switch {
+	case strings.Contains(decl, "INT"):
+		return affinityInteger
+	case strings.Contains(decl, "CHAR"), strings.Contains(decl, "CLOB"), strings.Contains(decl, "TEXT"):
+		return affinityText
+	case decl == "", strings.Contains(decl, "BLOB"):
+		return affinityBlob
+	case strings.Contains(decl, "REAL"), strings.Contains(decl, "FLOA"), strings.Contains(decl, "DOUB"):
+		return affinityReal
+	default:
+		return affinityNumeric
+	}
+}
+
+// Vet проверяет запросы по структуре базы данных: запросы должны разбираться, количество
+// параметров должно совпадать с описанием входящих параметров, а количество колонок ответа -
+// с описанием исходящих. Несовпадение названий и типов колонок с описанием полей возвращается
+// с уровнем важности [config.SeverityWarning]. Типы полей, которые соответствуют типам колонок
+// в types, считаются совместимыми; если соответствие не задано, то используется [DefaultTypes].
+func (s *Schema) Vet(qs config.Queries, types TypeMap) config.Errors {
+	if types == nil {
+		types = DefaultTypes()
+	}
+
+	var errs config.Errors
+	for _, q := range qs.Queries {
+		errs = append(errs, s.vet(q, types)...)
+	}
+
+	return errs
+}
+
+// vet проверяет запрос по структуре базы данных.
+func (s *Schema) vet(q config.Query, types TypeMap) config.Errors {
+	var errs config.Errors
+
+	st, err := s.Describe(q.SQL.Query)
+	if err != nil {
+		errs.Add(q.SQLErrorf(config.CodeSchema, err, "invalid query"))
+		return errs
+	}
+
+	if st.Params != len(q.In.Fields) {
+		errs.Add(q.SQLErrorf(config.CodeParamCount, nil,
+			"query uses %d parameter(s), but %d described", st.Params, len(q.In.Fields)))
+	}
+
+	// колонки ответа проверяются только для запросов, которые возвращают данные
+	if q.Type != config.TypeOne && q.Type != config.TypeMany {
+		return errs
+	}
+
+	if len(st.Columns) != len(q.Out.Fields) {
+		errs.Add(q.SQLErrorf(config.CodeColumnCount, nil,
+			"query returns %d column(s), but %d described", len(st.Columns), len(q.Out.Fields)))
+		return errs
+	}
+
+	outer := outerJoin(q.SQL.Query)
+	for i, col := range st.Columns {
+		f := q.Out.Fields[i]
+
+		// названия выражений без псевдонима не сравниваются
+		if isIdent(col.Name) && !strings.EqualFold(col.Name, f.Name) {
+			errs.Add(warning(q.FieldErrorf(f, config.CodeColumnName, nil,
+				"column #%d is %q, but field is %q", i+1, col.Name, f.Name)))
+		}
+
+		// типы выражений неизвестны
+		if col.Type == "" {
+			continue
+		}
+
+		// тип поля задан в соответствии типов колонок; колонки присоединённых внешним
+		// соединением таблиц могут содержать NULL, как и при определении типов [Schema.Infer]
+		canBeNull := col.Table != "" && outer.column(col)
+		if t, ok := types.Lookup(col.Type); ok && (f.Type == t.Type || f.Type == t.NullableType()) {
+			if canBeNull && f.Type != t.NullableType() {
+				errs.Add(warning(q.FieldErrorf(f, config.CodeColumnType, nil,
+					"column %q may be NULL, but field %q type %s can't hold it", col.Name, f.Name, f.Type)))
+			}
+
+			continue
+		}
+
+		affinities, nullable, ok := fieldAffinities(f.Type)
+		if !ok {
+			continue // пользовательские типы могут читать любые значения
+		}
+
+		if a := columnAffinity(col.Type); !hasAffinity(affinities, a) {
+			errs.Add(warning(q.FieldErrorf(f, config.CodeColumnType, nil,
+				"column %q type %s doesn't match field %q type %s", col.Name, col.Type, f.Name, f.Type)))
+		}
+
+		if canBeNull && !nullable {
+			errs.Add(warning(q.FieldErrorf(f, config.CodeColumnType, nil,
+				"column %q may be NULL, but field %q type %s can't hold it", col.Name, f.Name, f.Type)))
+		}
+	}
+
+	return errs
+}
+
+// Типы колонок, значения которых могут быть прочитаны в поля разных типов golang.
+var (
+	integerAffinities = []affinity{affinityInteger, affinityNumeric}
+	realAffinities    = []affinity{affinityReal, affinityNumeric, affinityInteger}
+	textAffinities    = []affinity{affinityText, affinityNumeric}
+	bytesAffinities   = []affinity{affinityText, affinityBlob}
+	timeAffinities    = []affinity{affinityText, affinityNumeric, affinityInteger, affinityReal}
+)
+
+// fieldAffinities возвращает типы колонок, значения которых могут быть прочитаны в поле типа typ,
+// и может ли поле содержать NULL. Для типов, которые не известны, возвращается false.
+func fieldAffinities(typ string) (affinities []affinity, nullable, ok bool) {
+	expr, err := config.ParseType(typ)
+	if err != nil {
+		return nil, false, false
+	}
+
+	// указатель остаётся nil при значении NULL
+	if star, isStar := expr.(*ast.StarExpr); isStar {
+		expr, nullable = star.X, true
+	}
+
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		switch expr.Name {
+		case "int", "int8", "int16", "int32", "int64",
+			"uint", "uint8", "uint16", "uint32", "uint64", "byte", "rune", "bool":
+			return integerAffinities, nullable, true
+		case "float32", "float64":
+			return realAffinities, nullable, true
+		case "string":
+			return textAffinities, nullable, true
+		}
+
+	case *ast.ArrayType:
+		if ident, isIdent := expr.Elt.(*ast.Ident); isIdent && expr.Len == nil &&
+			(ident.Name == "byte" || ident.Name == "uint8") {
+			return bytesAffinities, true, true
+		}
+
+	case *ast.SelectorExpr:
+		pkg, isIdent := expr.X.(*ast.Ident)
+		if !isIdent {
+			break
+		}
+
+		switch pkg.Name + "." + expr.Sel.Name {
+		case "time.Time":
+			return timeAffinities, nullable, true
+		case "json.RawMessage":
+			return bytesAffinities, true, true
+		case "sql.NullString":
+			return textAffinities, true, true
+		case "sql.NullBool", "sql.NullByte", "sql.NullInt16", "sql.NullInt32", "sql.NullInt64":
+			return integerAffinities, true, true
+		case "sql.NullFloat64":
+			return realAffinities, true, true
+		case "sql.NullTime":
+			return timeAffinities, true, true
+		}
+	}
+
+	return nil, false, false
+}
+
+// hasAffinity возвращает true, если тип колонки есть в списке.
+func hasAffinity(list []affinity, a affinity) bool {
+	for _, item := range list {
+		if item == a {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isIdent возвращает true, если название колонки является идентификатором, а не текстом выражения.
+func isIdent(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for _, r := range name {
+		if r != '_' && !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// warning возвращает ошибку с уровнем важности предупреждения.
+func warning(err error) config.Error {
+	qerr := err.(config.Error)
+	qerr.Severity = config.SeverityWarning
+
+	return qerr
+}
@@ -0,0 +1,106 @@
+package schema
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mdigger/sqlgen/config"
+)
+
+// testDDL описывает структуру базы данных для тестов.
+const testDDL = `
+create table users (
+	id integer primary key,
+	name text not null
+);
+create table orders (
+	id integer primary key,
+	user_id integer not null references users (id),
+	total real not null
+);
+`
+
+// testSchema возвращает базу данных со структурой testDDL.
+func testSchema(t *testing.T) *Schema {
+	t.Helper()
+	return newSchema(t, testDDL)
+}
+
+// parseQueries разбирает описание запросов.
+func parseQueries(t *testing.T, data string) *config.Queries {
+	t.Helper()
+
+	qs, err := config.ParseBytes("queries.yaml", []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return qs
+}
+
+// problems возвращает описания проблем в виде кода и сообщения.
+func problems(errs config.Errors) []string {
+	list := make([]string, 0, len(errs))
+	for _, err := range errs {
+		list = append(list, string(err.Code)+" "+err.Message)
+	}
+
+	return list
+}
+
+func TestVetOuterJoin(t *testing.T) {
+	s := testSchema(t)
+
+	for _, tt := range []struct {
+		name string
+		data string
+		want []string
+	}{{
+		name: "inner join",
+		data: `
+user orders:
+  type: many
+  sql: select u.name, o.total from users u join orders o on o.user_id = u.id
+  out:
+    name: string
+    total: float64
+`,
+	}, {
+		name: "left join",
+		data: `
+user orders:
+  type: many
+  sql: select u.name, o.total from users u left join orders o on o.user_id = u.id
+  out:
+    name: string
+    total: float64
+`,
+		want: []string{`SG021 column "total" may be NULL, but field "total" type float64 can't hold it`},
+	}, {
+		name: "left join nullable",
+		data: `
+user orders:
+  type: many
+  sql: select u.name, o.total from users u left join orders o on o.user_id = u.id
+  out:
+    name: string
+    total: sql.NullFloat64
+`,
+	}, {
+		name: "right join",
+		data: `
+user orders:
+  type: many
+  sql: select u.name, o.total from orders o right join users u on o.user_id = u.id
+  out:
+    name: string
+    total: '*float64'
+`,
+		want: []string{`SG021 column "name" may be NULL, but field "name" type string can't hold it`},
+	}} {
+		qs := parseQueries(t, tt.data)
+		if got := problems(s.Vet(*qs, nil)); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: problems = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/mdigger/sqlgen/config"
+	"github.com/mdigger/sqlgen/generator"
+	"github.com/mdigger/sqlgen/schema"
+	"github.com/urfave/cli/v3"
+)
+
+// vetFlags содержит флаги команды проверки запросов по структуре базы данных.
+var vetFlags = []cli.Flag{
+	&cli.StringSliceFlag{
+		Name:    "schema",
+		Usage:   "SQL `file` with the database schema (DDL); can be repeated",
+		Aliases: []string{"s"},
+	},
+	&cli.PathFlag{
+		Name:    "config",
+		Usage:   "project configuration `file` (default: " + config.ProjectFile + ", if exists)",
+		Aliases: []string{"c"},
+	},
+	&cli.StringSliceFlag{
+		Name:    "target",
+		Usage:   "process only the `target` with the given name from the project configuration",
+		Aliases: []string{"t"},
+	},
+	diagnosticsFlag,
+}
+
+// vetCmd загружает структуру базы данных каждой цели генерации во встроенную базу данных
+// SQLite в памяти и проверяет по ней все запросы из файлов с их описанием.
+func vetCmd(c *cli.Context, problems *config.Errors) error {
+	project, targets, err := buildTargets(c)
+	if err != nil {
+		return err
+	}
+
+	projectFile := configFile(c.Path("config"))
+	var count int
+	for _, t := range targets {
+		n, err := vetTarget(project, t, projectFile, problems)
+		if err != nil {
+			if t.Name != "" {
+				return fmt.Errorf("target %q: %w", t.Name, err)
+			}
+
+			return err
+		}
+
+		count += n
+	}
+
+	if len(*problems) > 0 {
+		return errProblems
+	}
+
+	log.Printf("vet completed: %d query(ies) ok", count)
+
+	return nil
+}
+
+// vetTarget проверяет запросы цели генерации t по её структуре базы данных и возвращает
+// количество проверенных запросов. Типы колонок сравниваются с типами полей с учётом
+// соответствия типов из настроек проекта.
+func vetTarget(project *config.Project, t config.Target, projectFile string, problems *config.Errors) (int, error) {
+	if len(t.Schema) == 0 {
+		return 0, errors.New(`database schema is not set: use the "schema" flag or target property`)
+	}
+
+	gen := generator.New(t.Out, t.Imports...)
+	gen.Dialect = t.Dialect
+	if err := gen.SetTypes(project.SQLTypes...); err != nil {
+		return 0, err
+	}
+
+	files, err := inputFiles(t.Sources, projectFile)
+	if err != nil {
+		return 0, err
+	}
+
+	db, err := schema.Load(t.Schema...)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	var count int
+	for _, file := range files {
+		qs, err := config.Parse(file)
+		if err != nil {
+			problems.Add(err)
+			continue
+		}
+
+		// не описанные явно исходящие параметры определяются по структуре базы данных
+		if errs := db.Infer(qs, gen.Types); len(errs) > 0 {
+			*problems = append(*problems, errs...)
+			continue
+		}
+
+		*problems = append(*problems, db.Vet(*qs, gen.Types)...)
+		count += len(qs.Queries)
+	}
+
+	return count, nil
+}
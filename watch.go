@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/mdigger/sqlgen/config"
+	"github.com/urfave/cli/v3"
+)
+
+// defaultWatchInterval задаёт интервал опроса отслеживаемых файлов по умолчанию.
+const defaultWatchInterval = 500 * time.Millisecond
+
+// fileState описывает состояние отслеживаемого файла.
+type fileState struct {
+	modTime time.Time // время изменения
+	size    int64     // размер
+}
+
+// snapshot содержит состояние отслеживаемых файлов по их именам.
+type snapshot map[string]fileState
+
+// watchCmd выполняет генерацию кода библиотеки при каждом изменении файлов с описанием
+// запросов или файла с настройками проекта. Изменения отслеживаются периодическим опросом
+// файлов, поэтому не зависят от поддержки уведомлений в файловой системе. Найденные проблемы
+// выводятся без завершения работы команды.
+func watchCmd(c *cli.Context) error {
+	ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	interval := c.Duration("interval")
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+
+	var sources [][]string // файлы с описанием запросов по целям генерации
+	state, err := watchState(c, &sources)
+	if err != nil {
+		return err
+	}
+
+	for {
+		regenerate(c)
+		log.Println("watching for changes...")
+
+		next, err := waitChanges(ctx, c, &sources, state, interval)
+		if errors.Is(err, context.Canceled) {
+			return nil // работа прервана пользователем
+		}
+
+		if err != nil {
+			return err
+		}
+
+		for _, line := range state.diff(next) {
+			log.Println(line)
+		}
+
+		state = next
+	}
+}
+
+// regenerate выполняет генерацию кода библиотеки и выводит найденные проблемы.
+func regenerate(c *cli.Context) {
+	var problems config.Errors
+	if err := generate(c, &problems); err != nil && !errors.Is(err, errProblems) {
+		problems.Add(err)
+	}
+
+	if err := printProblems(c, problems); err != nil {
+		log.Println("error:", err)
+	}
+}
+
+// waitChanges ожидает изменения отслеживаемых файлов и возвращает их новое состояние.
+// Изменения возвращаются только после того, как файлы не менялись в течение одного
+// интервала опроса, чтобы не запускать генерацию на каждое промежуточное сохранение.
+func waitChanges(ctx context.Context, c *cli.Context, sources *[][]string, state snapshot,
+	interval time.Duration,
+) (snapshot, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var pending snapshot // обнаруженные, но ещё не устоявшиеся изменения
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		next, err := watchState(c, sources)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case pending != nil && pending.equal(next):
+			return next, nil
+		case !state.equal(next):
+			pending = next
+		default:
+			pending = nil // файлы вернулись в исходное состояние
+		}
+	}
+}
+
+// watchState возвращает состояние файлов с описанием запросов и структуры базы данных всех
+// целей генерации и файла с настройками проекта. Если настройки проекта не удаётся разобрать,
+// то отслеживаются файлы целей из последних корректных настроек sources, чтобы исправление
+// настроек или описаний запросов запустило генерацию заново.
+func watchState(c *cli.Context, sources *[][]string) (snapshot, error) {
+	if _, targets, err := buildTargets(c); err == nil {
+		*sources = (*sources)[:0]
+		for _, t := range targets {
+			*sources = append(*sources, t.Sources)
+			if len(t.Schema) > 0 {
+				*sources = append(*sources, t.Schema)
+			}
+		}
+	}
+
+	projectFile := configFile(c.Path("config"))
+	names := []string{projectFile}
+	for _, patterns := range *sources {
+		files, err := matchFiles(patterns, projectFile)
+		if err != nil {
+			return nil, err
+		}
+
+		names = append(names, files...)
+	}
+
+	state := make(snapshot, len(names))
+	for _, name := range names {
+		info, err := os.Stat(name)
+		if err != nil {
+			continue // файл удалён или файла с настройками проекта нет
+		}
+
+		state[name] = fileState{modTime: info.ModTime(), size: info.Size()}
+	}
+
+	return state, nil
+}
+
+// equal возвращает true, если состояние файлов не изменилось.
+func (s snapshot) equal(other snapshot) bool {
+	if len(s) != len(other) {
+		return false
+	}
+
+	for name, state := range s {
+		if o, ok := other[name]; !ok || o.size != state.size || !o.modTime.Equal(state.modTime) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// diff возвращает отсортированный список описаний изменений файлов.
+func (s snapshot) diff(other snapshot) []string {
+	var lines []string
+	for name, state := range other {
+		switch o, ok := s[name]; {
+		case !ok:
+			lines = append(lines, "added:    "+name)
+		case o.size != state.size || !o.modTime.Equal(state.modTime):
+			lines = append(lines, "changed:  "+name)
+		}
+	}
+
+	for name := range s {
+		if _, ok := other[name]; !ok {
+			lines = append(lines, "deleted:  "+name)
+		}
+	}
+
+	sort.Strings(lines)
+
+	return lines
+}
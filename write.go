@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// writeFiles записывает сгенерированные файлы так, чтобы либо все они были записаны, либо
+// каталог остался без изменений: сначала содержимое записывается во временные файлы в
+// том же каталоге, и только после успешной записи всех файлов они атомарно переименовываются.
+func writeFiles(files []generatedFile) (err error) {
+	temps := make([]string, 0, len(files)) // временные файлы в порядке списка файлов
+	defer func() {
+		if err != nil {
+			for _, name := range temps {
+				_ = os.Remove(name) // удаляем временные файлы в случае ошибки
+			}
+		}
+	}()
+
+	for _, file := range files {
+		name, err := writeTemp(file)
+		if err != nil {
+			return err
+		}
+
+		temps = append(temps, name)
+	}
+
+	var errs []error
+	for i, file := range files {
+		if err := os.Rename(temps[i], file.Name); err != nil {
+			_ = os.Remove(temps[i])
+			errs = append(errs, fmt.Errorf("save file %q: %w", file.Name, err))
+		}
+	}
+
+	temps = nil // все временные файлы уже переименованы или удалены
+
+	return errors.Join(errs...)
+}
+
+// changedFiles возвращает список файлов, содержимое которых отличается от уже записанного
+// на диск. Файлы с неизменным содержимым не перезаписываются, чтобы не менять время их
+// изменения и не сбрасывать кеш сборки.
+func changedFiles(files []generatedFile) ([]generatedFile, error) {
+	changed := make([]generatedFile, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file.Name)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return nil, fmt.Errorf("read file %q: %w", file.Name, err)
+		case bytes.Equal(data, file.Data):
+			continue
+		}
+
+		changed = append(changed, file)
+	}
+
+	return changed, nil
+}
+
+// writeTemp записывает содержимое файла во временный файл в том же каталоге и
+// возвращает его имя. Временный файл получает права доступа существующего файла, чтобы
+// они не изменились после переименования; новые файлы создаются с правами 0600.
+func writeTemp(file generatedFile) (string, error) {
+	dir, base := filepath.Split(file.Name)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+base+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("save file %q: %w", file.Name, err)
+	}
+
+	if info, err := os.Stat(file.Name); err == nil {
+		if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+
+			return "", fmt.Errorf("save file %q: %w", file.Name, err)
+		}
+	}
+
+	if _, err := tmp.Write(file.Data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+
+		return "", fmt.Errorf("save file %q: %w", file.Name, err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+
+		return "", fmt.Errorf("save file %q: %w", file.Name, err)
+	}
+
+	return tmp.Name(), nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// tempFiles возвращает временные файлы, оставшиеся в каталоге dir.
+func tempFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	names, err := filepath.Glob(filepath.Join(dir, ".*.tmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return names
+}
+
+func TestWriteUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	same := filepath.Join(dir, "same.sql.go")
+	changed := filepath.Join(dir, "changed.sql.go")
+	for _, name := range []string{same, changed} {
+		if err := os.WriteFile(name, []byte("package db\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// время изменения в прошлом, чтобы перезапись была заметна
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(same, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := changedFiles([]generatedFile{
+		{Name: same, Data: []byte("package db\n")},
+		{Name: changed, Data: []byte("package db\n\n// changed\n")},
+		{Name: filepath.Join(dir, "new.sql.go"), Data: []byte("package db\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 2 || files[0].Name != changed || files[1].Name != filepath.Join(dir, "new.sql.go") {
+		t.Fatalf("changed files = %v", files)
+	}
+
+	if err := writeFiles(files); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(same)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !info.ModTime().Equal(past) {
+		t.Errorf("unchanged file rewritten: modified %v, want %v", info.ModTime(), past)
+	}
+
+	if data, err := os.ReadFile(changed); err != nil || string(data) != "package db\n\n// changed\n" {
+		t.Errorf("changed file = %q, %v", data, err)
+	}
+
+	if names := tempFiles(t, dir); len(names) > 0 {
+		t.Errorf("temporary files left: %v", names)
+	}
+}
+
+func TestWriteCleanup(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.sql.go")
+	if err := os.WriteFile(first, []byte("package old\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// второй файл не может быть создан: каталога не существует
+	err := writeFiles([]generatedFile{
+		{Name: first, Data: []byte("package db\n")},
+		{Name: filepath.Join(dir, "missing", "second.sql.go"), Data: []byte("package db\n")},
+	})
+	if err == nil {
+		t.Fatal("write to a missing folder succeeded")
+	}
+
+	if data, _ := os.ReadFile(first); string(data) != "package old\n" {
+		t.Errorf("first file changed: %q", data)
+	}
+
+	if names := tempFiles(t, dir); len(names) > 0 {
+		t.Errorf("temporary files left: %v", names)
+	}
+
+	// переименование во временный файл не удаётся: на месте файла каталог
+	busy := filepath.Join(dir, "busy.sql.go")
+	if err := os.MkdirAll(filepath.Join(busy, "child"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFiles([]generatedFile{{Name: busy, Data: []byte("package db\n")}}); err == nil {
+		t.Fatal("rename over a folder succeeded")
+	}
+
+	if names := tempFiles(t, dir); len(names) > 0 {
+		t.Errorf("temporary files left after rename error: %v", names)
+	}
+}
+
+func TestWritePermissions(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.sql.go")
+	if err := os.WriteFile(existing, []byte("package old\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chmod(existing, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	created := filepath.Join(dir, "created.sql.go")
+	if err := writeFiles([]generatedFile{
+		{Name: existing, Data: []byte("package db\n")},
+		{Name: created, Data: []byte("package db\n")},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]os.FileMode{existing: 0o644, created: 0o600} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("%s: mode = %v, want %v", filepath.Base(name), got, want)
+		}
+	}
+}